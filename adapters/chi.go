@@ -25,6 +25,12 @@ func (a *ChiAdapter) Handle(route *zorya.BaseRoute, handler http.HandlerFunc) {
 	a.router.MethodFunc(route.Method, route.Path, handler)
 }
 
+// HandleMethodNotAllowed sets the handler of requests whose method is not
+// registered for their path. It implements zorya.MethodNotAllowedAdapter.
+func (a *ChiAdapter) HandleMethodNotAllowed(handler http.HandlerFunc) {
+	a.router.MethodNotAllowed(handler)
+}
+
 func (a *ChiAdapter) ExtractRouterParams(r *http.Request, route *zorya.BaseRoute) map[string]string {
 	routerParams := make(map[string]string)
 	chiCtx := chi.RouteContext(r.Context())
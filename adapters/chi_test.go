@@ -0,0 +1,44 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/talav/zorya"
+)
+
+func TestChiAdapter_MethodNotAllowed(t *testing.T) {
+	router := chi.NewMux()
+	api := zorya.NewAPI(NewChi(router))
+
+	type Out struct {
+		Body string
+	}
+
+	zorya.Get(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*Out, error) {
+		return &Out{Body: "item"}, nil
+	})
+	zorya.Delete(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/items/1", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS", recorder.Header().Get("Allow"))
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "problem+json")
+	assert.Contains(t, recorder.Body.String(), "method POST not allowed")
+
+	// Unknown paths are still not found
+	req = httptest.NewRequest(http.MethodPost, "/other", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
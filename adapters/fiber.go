@@ -3,6 +3,7 @@ package adapters
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
@@ -61,33 +62,54 @@ func (a *FiberAdapter) Handle(route *zorya.BaseRoute, handler http.HandlerFunc)
 			}
 		}
 
-		// Convert fiber.Ctx to http.Request/ResponseWriter
-		r := c.Request()
-		w := &fiberResponseWriter{ctx: c}
-
-		// Create http.Request from fiber request
-		req, _ := http.NewRequestWithContext(
-			c.UserContext(),
-			string(r.Header.Method()),
-			c.OriginalURL(),
-			bytes.NewReader(c.BodyRaw()),
-		)
-		// Copy headers
-		r.Header.VisitAll(func(key, value []byte) {
-			req.Header.Set(string(key), string(value))
-		})
+		serveFiber(c, handler, routerParams)
 
-		// Store router params in request context for ExtractRouterParams
-		ctx := context.WithValue(req.Context(), routerParamsKey, routerParams)
-		req = req.WithContext(ctx)
+		return nil
+	})
+}
 
-		// Call the standard http.HandlerFunc (with middleware already applied)
-		handler(w, req)
+// HandleMethodNotAllowed sets the handler of requests whose method is not
+// registered for their path, which Fiber reports as fiber.ErrMethodNotAllowed.
+// It implements zorya.MethodNotAllowedAdapter.
+func (a *FiberAdapter) HandleMethodNotAllowed(handler http.HandlerFunc) {
+	a.app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if errors.Is(err, fiber.ErrMethodNotAllowed) {
+			serveFiber(c, handler, map[string]string{})
 
-		return nil
+			return nil
+		}
+
+		return err
 	})
 }
 
+// serveFiber runs a standard http.HandlerFunc for the Fiber request.
+func serveFiber(c *fiber.Ctx, handler http.HandlerFunc, routerParams map[string]string) {
+	// Convert fiber.Ctx to http.Request/ResponseWriter
+	r := c.Request()
+	w := &fiberResponseWriter{ctx: c}
+
+	// Create http.Request from fiber request
+	req, _ := http.NewRequestWithContext(
+		c.UserContext(),
+		string(r.Header.Method()),
+		c.OriginalURL(),
+		bytes.NewReader(c.BodyRaw()),
+	)
+	// Copy headers
+	r.Header.VisitAll(func(key, value []byte) {
+		req.Header.Set(string(key), string(value))
+	})
+
+	// Store router params in request context for ExtractRouterParams
+	ctx := context.WithValue(req.Context(), routerParamsKey, routerParams)
+	req = req.WithContext(ctx)
+
+	// Call the standard http.HandlerFunc (with middleware already applied)
+	handler(w, req)
+}
+
 func (a *FiberAdapter) ExtractRouterParams(r *http.Request, route *zorya.BaseRoute) map[string]string {
 	// For Fiber, params are extracted in Handle from fiber.Ctx
 	// If called from Register, try to get from context (stored by Handle)
@@ -138,3 +160,8 @@ func (w *fiberResponseWriter) WriteHeader(statusCode int) {
 	w.syncHeaders()
 	w.ctx.Status(statusCode)
 }
+
+// Flush is a no-op: Fiber sends the response once the handler returns. It
+// lets streaming bodies and response compression flush through
+// http.ResponseController.
+func (w *fiberResponseWriter) Flush() {}
@@ -1,7 +1,9 @@
 package adapters
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -39,3 +41,76 @@ func TestFiberAdapter_HeadersPropagate(t *testing.T) {
 	assert.NotEmpty(t, ct, "Content-Type must be set by handler via w.Header().Set")
 	assert.Contains(t, ct, "json", "response should be JSON")
 }
+
+func TestFiberAdapter_Compression(t *testing.T) {
+	app := fiber.New()
+	api := zorya.NewAPI(NewFiber(app), zorya.WithCompression(&zorya.Compression{MinSize: -1}))
+
+	type Out struct {
+		Body func(http.ResponseWriter) error
+	}
+
+	zorya.Get(api, "/stream", func(ctx context.Context, _ *struct{}) (*Out, error) {
+		return &Out{Body: func(w http.ResponseWriter) error {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("hello "))
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				return err
+			}
+			_, err := w.Write([]byte("world"))
+
+			return err
+		}}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(body))
+}
+
+func TestFiberAdapter_MethodNotAllowed(t *testing.T) {
+	app := fiber.New()
+	api := zorya.NewAPI(NewFiber(app))
+
+	type Out struct {
+		Body string
+	}
+
+	zorya.Get(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*Out, error) {
+		return &Out{Body: "item"}, nil
+	})
+	zorya.Delete(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/items/1", nil)
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS", resp.Header.Get("Allow"))
+	assert.Contains(t, resp.Header.Get("Content-Type"), "problem+json")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "method POST not allowed")
+
+	// Unknown paths are still not found
+	req = httptest.NewRequest(http.MethodPost, "/other", nil)
+	resp, err = app.Test(req, -1)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
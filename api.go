@@ -5,10 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"maps"
 	"net/http"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/talav/mapstructure"
 	"github.com/talav/negotiation"
@@ -36,6 +41,16 @@ type Adapter interface {
 	ServeHTTP(http.ResponseWriter, *http.Request)
 }
 
+// MethodNotAllowedAdapter is implemented by adapters whose router answers
+// requests for a registered path with an unregistered method itself. NewAPI
+// sets the handler of those requests, so they get the same 405 Method Not
+// Allowed response, with the Allow header, whichever router is used.
+type MethodNotAllowedAdapter interface {
+	// HandleMethodNotAllowed sets the handler of requests whose path matches a
+	// route but whose method does not.
+	HandleMethodNotAllowed(handler http.HandlerFunc)
+}
+
 // Codec is an interface that allows to map request and router parameters into Go structures.
 type Codec interface {
 	// DecodeRequest decodes an HTTP request into the provided struct.
@@ -66,7 +81,7 @@ type Transformer func(r *http.Request, status int, result any) (any, error)
 // API is the core interface for a Zorya API. It provides request/response handling,
 // content negotiation, validation, and OpenAPI spec generation.
 //
-//nolint:interfacebloat // API is the core framework interface; 14 methods is reasonable for a complete API contract
+//nolint:interfacebloat // API is the core framework interface; its 16 exported methods form the API contract, the unexported ones are registration and serving internals
 type API interface {
 	// Adapter returns the router adapter for this API, providing a generic
 	// interface to get request information and write responses.
@@ -87,13 +102,21 @@ type API interface {
 	Metadata() *schema.Metadata
 
 	// Negotiate returns the best content type for the response based on the
-	// Accept header. If no match is found, returns the default format.
+	// Accept header. If no match is found, returns the default format, or a
+	// 406 Not Acceptable StatusError if Config.NoFormatFallback is set.
 	Negotiate(accept string) (string, error)
 
 	// Marshal writes the value to the writer using the format for the given
 	// content type. Supports plus-segment matching (e.g., application/vnd.api+json).
-	// If marshaling fails, it falls back to plain text representation.
-	Marshal(w io.Writer, contentType string, v any)
+	// Returns an error if no format is registered for the content type or
+	// marshaling fails.
+	Marshal(w io.Writer, contentType string, v any) error
+
+	// Unmarshal decodes data into v using the format for the given content
+	// type. Supports plus-segment matching (e.g., application/vnd.api+json).
+	// Returns a 415 Unsupported Media Type StatusError listing the accepted
+	// media types if no format can decode the content type.
+	Unmarshal(contentType string, data []byte, v any) error
 
 	// Validator returns the configured validator, or nil if validation is disabled.
 	Validator() Validator
@@ -110,28 +133,94 @@ type API interface {
 	// until the server starts.
 	OpenAPI() *OpenAPI
 
+	// Spec returns the OpenAPI specification generated from the registered
+	// operations, encoded as JSON.
+	Spec(ctx context.Context) ([]byte, error)
+
+	// Authorizer returns the configured authorizer, or nil if security
+	// requirements are only stored in the request context.
+	Authorizer() Authorizer
+
+	// PanicHandler returns the configured panic handler, or nil if recovered
+	// panics are only logged.
+	PanicHandler() PanicHandler
+
+	// Routes returns the operations registered so far, in registration order,
+	// e.g. to list them or check that every write route is secured. Routes
+	// registered with a group are included with their resolved path. Endpoints
+	// added by Zorya itself (spec, docs and schemas) are not.
+	Routes() []RouteInfo
+
 	// addOperationToState registers an operation for OpenAPI generation.
 	// Internal method used during route registration.
-	addOperationToState(op openapi.Operation)
+	addOperationToState(op openapi.Operation, route *BaseRoute)
+
+	// resolveRoutes applies route modifiers (e.g. group prefixes and security)
+	// and returns the routes to register. Internal method used during route
+	// registration.
+	resolveRoutes(route *BaseRoute) []*BaseRoute
+
+	// registerRoutes registers the handlers of resolved routes with the
+	// adapter, or none of them if a route's method and path are taken.
+	// Internal method used during route registration.
+	registerRoutes(routes []*BaseRoute, handlers []http.Handler) error
+
+	// reportMarshalError passes a response marshaling failure to the
+	// configured MarshalErrorHandler. Internal method used when writing responses.
+	reportMarshalError(r *http.Request, contentType string, err error)
+
+	// responseValidation returns the configured response validation mode.
+	// Internal method used during route registration.
+	responseValidation() ResponseValidationMode
+
+	// checkResponse checks a buffered response against the route's documented
+	// responses. Internal method used by response validation.
+	checkResponse(r *http.Request, route *BaseRoute, status, handlerStatus int, header http.Header, body []byte) error
+
+	// compressionConfig returns the response compression configuration, or nil
+	// if compression is disabled. Internal method used during route registration.
+	compressionConfig() *Compression
+
+	// registeredRoutes returns the routes registered so far, in registration
+	// order. Internal method used by GenerateClient.
+	registeredRoutes() []*BaseRoute
+
+	// encodedSpec returns the spec in the given encoding. Internal method used
+	// by ExportSpec.
+	encodedSpec(ctx context.Context, format specFormat) ([]byte, error)
 }
 
 // Option configures an API.
 type Option func(*api)
 
+// MarshalErrorHandler is called when a response body cannot be marshaled in
+// the negotiated content type.
+type MarshalErrorHandler func(r *http.Request, contentType string, err error)
+
 type api struct {
-	adapter          Adapter
-	middlewares      Middlewares
-	codec            *schema.Codec
-	metadata         *schema.Metadata
-	formats          map[string]Format
-	formatKeys       []string
-	defaultFormat    string
-	negotiator       *negotiation.Negotiator
-	validator        Validator
-	transformers     []Transformer
-	config           *Config
-	openAPI          *OpenAPI
-	openapiState     *openapiState // Uses github.com/talav/openapi for schema generation
+	adapter                Adapter
+	middlewares            Middlewares
+	codec                  *schema.Codec
+	metadata               *schema.Metadata
+	formats                map[string]Format
+	formatKeys             []string
+	requestFormatKeys      []string // Media types whose format can decode request bodies
+	defaultFormat          string
+	negotiator             *negotiation.Negotiator
+	validator              Validator
+	authorizer             Authorizer
+	panicHandler           PanicHandler
+	marshalErrorHandler    MarshalErrorHandler
+	responseValidationMode ResponseValidationMode
+	transformers           []Transformer
+	config                 *Config
+	openAPI                *OpenAPI
+	openapiState           *openapiState // Uses github.com/talav/openapi for schema generation
+	routes                 *routeTable   // Handlers by path and method, used to derive HEAD, OPTIONS and 405 responses
+	cors                   *CORS         // Default CORS configuration of routes
+	compression            *Compression  // Response compression, nil if disabled
+	docsUI                 *DocsUI       // Docs UI renderer, Stoplight Elements if nil
+	docsErr                error         // Docs UI configuration error, served by the docs endpoint
 }
 
 func (a *api) Adapter() Adapter {
@@ -159,12 +248,49 @@ func (a *api) Validator() Validator {
 	return a.validator
 }
 
+func (a *api) Authorizer() Authorizer {
+	return a.authorizer
+}
+
+func (a *api) PanicHandler() PanicHandler {
+	return a.panicHandler
+}
+
 func (a *api) OpenAPI() *OpenAPI {
 	return a.openAPI
 }
 
-func (a *api) addOperationToState(op openapi.Operation) {
-	a.openapiState.AddOperation(op)
+func (a *api) Spec(ctx context.Context) ([]byte, error) {
+	specJSON, _, err := a.openapiState.GenerateSpec(ctx)
+
+	return specJSON, err
+}
+
+func (a *api) encodedSpec(ctx context.Context, format specFormat) ([]byte, error) {
+	spec, _, err := a.openapiState.GenerateSpecFormat(ctx, format)
+
+	return spec, err
+}
+
+func (a *api) addOperationToState(op openapi.Operation, route *BaseRoute) {
+	a.openapiState.AddOperation(op, route)
+}
+
+func (a *api) Routes() []RouteInfo {
+	routes := a.registeredRoutes()
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		infos = append(infos, routeInfo(route))
+	}
+
+	return infos
+}
+
+func (a *api) registeredRoutes() []*BaseRoute {
+	a.openapiState.mu.RLock()
+	defer a.openapiState.mu.RUnlock()
+
+	return slices.Clone(a.openapiState.routes)
 }
 
 // buildOpenapiOperation converts Zorya operation metadata to openapi.Operation.
@@ -222,13 +348,8 @@ func buildOpenapiOperation(method, path string, inputType, outputType reflect.Ty
 	}
 	opts = append(opts, openapi.WithResponse(500, errorInstance))
 
-	// Add security requirements
-	if route.Security != nil {
-		scopes := make([]string, 0, len(route.Security.Roles)+len(route.Security.Permissions))
-		scopes = append(scopes, route.Security.Roles...)
-		scopes = append(scopes, route.Security.Permissions...)
-		opts = append(opts, openapi.WithSecurity("bearerAuth", scopes...))
-	}
+	// Security requirements are resolved against the declared security
+	// schemes when the spec is generated (see openapiState.applyOverrides).
 
 	// Create operation with appropriate HTTP method
 	var op openapi.Operation
@@ -248,7 +369,10 @@ func buildOpenapiOperation(method, path string, inputType, outputType reflect.Ty
 	case http.MethodOptions:
 		op = openapi.OPTIONS(path, opts...)
 	default:
-		op = openapi.GET(path, opts...) // Fallback
+		// Other methods (TRACE, QUERY, custom methods) are generated at a
+		// placeholder path and moved to the route's path item when the spec
+		// is generated (see openapiState.applyOverrides).
+		op = openapi.GET(methodPlaceholderPath(method, path), opts...)
 	}
 
 	return op
@@ -273,6 +397,9 @@ func (a *api) UseTransformer(transformers ...Transformer) {
 }
 
 // Negotiate returns the best content type based on the Accept header.
+// If no format matches, it falls back to the default format unless
+// Config.NoFormatFallback is set, in which case a 406 Not Acceptable
+// StatusError listing the supported media types is returned.
 func (a *api) Negotiate(accept string) (string, error) {
 	if accept == "" {
 		return a.defaultFormat, nil
@@ -280,6 +407,10 @@ func (a *api) Negotiate(accept string) (string, error) {
 
 	header, err := a.negotiator.Negotiate(accept, a.formatKeys, false)
 	if errors.Is(err, negotiation.ErrNoMatch) {
+		if a.config.NoFormatFallback {
+			return "", Error406NotAcceptable("none of the requested media types are supported, supported media types: " + strings.Join(a.formatKeys, ", "))
+		}
+
 		// Fallback to default format when no match
 		return a.defaultFormat, nil
 	}
@@ -292,27 +423,56 @@ func (a *api) Negotiate(accept string) (string, error) {
 }
 
 // Marshal writes the value using the format for the given content type.
-// If marshaling fails, it falls back to plain text representation.
-func (a *api) Marshal(w io.Writer, ct string, v any) {
-	f, ok := a.formats[ct]
+func (a *api) Marshal(w io.Writer, ct string, v any) error {
+	f, ok := a.format(ct)
 	if !ok {
-		// Try extracting suffix from plus-segment (e.g., application/vnd.api+json -> json).
-		if idx := strings.LastIndex(ct, "+"); idx != -1 {
-			f, ok = a.formats[ct[idx+1:]]
-		}
+		return fmt.Errorf("no format registered for content type %q", ct)
 	}
 
-	if !ok {
-		// Unknown content type - fallback to plain text
-		_, _ = fmt.Fprintf(w, "%v", v)
+	if err := f.Marshal(w, v); err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ct, err)
+	}
+
+	return nil
+}
+
+// reportMarshalError passes a response marshaling failure to the marshal error
+// handler, or logs it if none is configured.
+func (a *api) reportMarshalError(r *http.Request, ct string, err error) {
+	if a.marshalErrorHandler != nil {
+		a.marshalErrorHandler(r, ct, err)
 
 		return
 	}
 
-	if err := f.Marshal(w, v); err != nil {
-		// Marshaling failed - fallback to plain text
-		_, _ = fmt.Fprintf(w, "%v", v)
+	log.Printf("zorya: failed to write response for %s %s: %v", r.Method, r.URL.Path, err)
+}
+
+// Unmarshal decodes data using the format for the content type.
+func (a *api) Unmarshal(ct string, data []byte, v any) error {
+	f, ok := a.format(ct)
+	if !ok || f.Unmarshal == nil {
+		return Error415UnsupportedMediaType(fmt.Sprintf("unsupported media type %q, supported media types: %s", ct, strings.Join(a.requestFormatKeys, ", ")))
+	}
+
+	if err := f.Unmarshal(data, v); err != nil {
+		return Error400BadRequest("failed to decode request body", err)
+	}
+
+	return nil
+}
+
+// format returns the format registered for the content type, falling back to
+// the plus-segment suffix (e.g., application/vnd.api+json -> json).
+func (a *api) format(ct string) (Format, bool) {
+	f, ok := a.formats[ct]
+	if !ok {
+		if idx := strings.LastIndex(ct, "+"); idx != -1 {
+			f, ok = a.formats[ct[idx+1:]]
+		}
 	}
+
+	return f, ok
 }
 
 // NewAPI creates a new API instance with the given adapter and options.
@@ -327,11 +487,11 @@ func (a *api) Marshal(w io.Writer, ct string, v any) {
 //	api := zorya.NewAPI(adapter, zorya.WithFormatsReplace(formats)) // Replace all formats
 func NewAPI(adapter Adapter, opts ...Option) API {
 	a := &api{
-		adapter:       adapter,
-		middlewares:   Middlewares{},
-		defaultFormat: "application/json",
-		negotiator:    negotiation.NewMediaNegotiator(),
-		transformers:  []Transformer{},
+		adapter:      adapter,
+		middlewares:  Middlewares{},
+		negotiator:   negotiation.NewMediaNegotiator(),
+		transformers: []Transformer{},
+		routes:       newRouteTable(),
 	}
 
 	// Apply options
@@ -345,7 +505,12 @@ func NewAPI(adapter Adapter, opts ...Option) API {
 		a.metadata = NewMetadata()
 	}
 	if a.codec == nil {
-		a.codec = schema.NewCodec(a.metadata, mapstructure.NewDefaultUnmarshaler(), schema.NewDefaultDecoder())
+		a.codec = schema.NewCodec(a.metadata, mapstructure.NewUnmarshaler(
+			mapstructure.NewDefaultStructMetadataCache(),
+			mapstructure.NewDefaultConverterRegistry(map[reflect.Type]mapstructure.Converter{
+				reflect.TypeFor[time.Time](): convertTime,
+			}),
+		), paramDecoder{schema.NewDefaultDecoder()})
 	}
 
 	if a.formats == nil {
@@ -358,15 +523,10 @@ func NewAPI(adapter Adapter, opts ...Option) API {
 		a.config = DefaultConfig()
 	}
 
-	// Build format keys from formats
-	if len(a.formatKeys) == 0 {
-		a.formatKeys = make([]string, 0, len(a.formats))
-		for k := range a.formats {
-			// Only include full content types, not suffixes
-			if strings.Contains(k, "/") {
-				a.formatKeys = append(a.formatKeys, k)
-			}
-		}
+	initializeFormats(a)
+
+	if adapter, ok := a.adapter.(MethodNotAllowedAdapter); ok {
+		adapter.HandleMethodNotAllowed(a.methodNotAllowed)
 	}
 
 	// Initialize the openapi state that uses github.com/talav/openapi library
@@ -374,10 +534,51 @@ func NewAPI(adapter Adapter, opts ...Option) API {
 
 	registerOpenAPIEndpoint(a)
 	registerDocsEndpoint(a)
+	registerSchemasEndpoint(a)
 
 	return a
 }
 
+// initializeFormats resolves the default format and builds the list of media
+// types offered during negotiation, with the default format first so that it
+// wins for wildcard Accept headers.
+func initializeFormats(a *api) {
+	// WithDefaultFormat takes precedence over Config.DefaultFormat
+	if a.defaultFormat == "" {
+		a.defaultFormat = a.config.DefaultFormat
+	}
+
+	// Build format keys from formats
+	a.formatKeys = make([]string, 0, len(a.formats))
+	for k := range a.formats {
+		// Only include full content types, not suffixes
+		if strings.Contains(k, "/") && k != a.defaultFormat {
+			a.formatKeys = append(a.formatKeys, k)
+		}
+	}
+	sort.Strings(a.formatKeys)
+
+	if a.defaultFormat == "" {
+		if _, ok := a.formats[contentTypeJSON]; ok || len(a.formatKeys) == 0 {
+			a.defaultFormat = contentTypeJSON
+		} else {
+			a.defaultFormat = a.formatKeys[0]
+		}
+	}
+
+	if _, ok := a.formats[a.defaultFormat]; ok {
+		a.formatKeys = slices.DeleteFunc(a.formatKeys, func(k string) bool { return k == a.defaultFormat })
+		a.formatKeys = append([]string{a.defaultFormat}, a.formatKeys...)
+	}
+
+	a.requestFormatKeys = make([]string, 0, len(a.formatKeys))
+	for _, k := range a.formatKeys {
+		if a.formats[k].Unmarshal != nil {
+			a.requestFormatKeys = append(a.requestFormatKeys, k)
+		}
+	}
+}
+
 // initializeOpenAPI initializes the OpenAPI spec and its Components if needed.
 func initializeOpenAPI(a *api) {
 	if a.openAPI == nil {
@@ -394,18 +595,46 @@ func initializeOpenAPI(a *api) {
 	}
 }
 
-// registerOpenAPIEndpoint registers the OpenAPI spec endpoint if configured.
+const (
+	contentTypeOpenAPIJSON = "application/vnd.oai.openapi+json"
+	contentTypeOpenAPIYAML = "application/vnd.oai.openapi"
+)
+
+// specMediaTypes are the media types the spec can be negotiated to on the JSON
+// spec paths, with JSON preferred for wildcards.
+var specMediaTypes = []string{
+	contentTypeOpenAPIJSON, contentTypeJSON,
+	contentTypeOpenAPIYAML, "application/yaml", "application/x-yaml", "text/yaml",
+}
+
+// registerOpenAPIEndpoint registers the OpenAPI spec endpoints if configured:
+// the spec as JSON and YAML, and downgraded to OpenAPI 3.0.
 func registerOpenAPIEndpoint(a *api) {
-	if a.config.OpenAPIPath == "" {
+	registerSpecEndpoint(a, a.config.OpenAPIPath, specFormat{})
+	registerSpecEndpoint(a, a.config.OpenAPIYAMLPath, specFormat{yaml: true})
+	registerSpecEndpoint(a, a.config.OpenAPI30Path, specFormat{openAPI30: true})
+	registerSpecEndpoint(a, a.config.OpenAPI30YAMLPath, specFormat{yaml: true, openAPI30: true})
+}
+
+// registerSpecEndpoint registers an endpoint serving the spec in the format.
+// JSON endpoints serve YAML to clients preferring it in the Accept header.
+func registerSpecEndpoint(a *api, path string, format specFormat) {
+	if path == "" {
 		return
 	}
 
 	a.adapter.Handle(&BaseRoute{
 		Method: http.MethodGet,
-		Path:   a.config.OpenAPIPath,
+		Path:   path,
 	}, func(w http.ResponseWriter, r *http.Request) {
+		format := format
+		if !format.yaml {
+			w.Header().Add("Vary", "Accept")
+			format.yaml = prefersYAMLSpec(a, r.Header.Get("Accept"))
+		}
+
 		// Generate spec with caching and ETag support
-		specJSON, etag, err := a.openapiState.GenerateSpec(r.Context())
+		spec, etag, err := a.openapiState.GenerateSpecFormat(r.Context(), format)
 		if err != nil {
 			WriteErr(a, r, w, http.StatusInternalServerError, "failed to generate OpenAPI spec", err)
 			return
@@ -420,12 +649,31 @@ func registerOpenAPIEndpoint(a *api) {
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/vnd.oai.openapi+json")
+		contentType := contentTypeOpenAPIJSON
+		if format.yaml {
+			contentType = contentTypeOpenAPIYAML
+		}
+		w.Header().Set("Content-Type", contentType)
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(specJSON)
+		_, _ = w.Write(spec)
 	})
 }
 
+// prefersYAMLSpec reports whether the Accept header prefers a YAML media type
+// over JSON for the spec.
+func prefersYAMLSpec(a *api, accept string) bool {
+	if accept == "" {
+		return false
+	}
+
+	header, err := a.negotiator.Negotiate(accept, specMediaTypes, false)
+	if err != nil {
+		return false
+	}
+
+	return slices.Index(specMediaTypes, header.Type) >= slices.Index(specMediaTypes, contentTypeOpenAPIYAML)
+}
+
 // WithValidator sets a validator for request validation.
 func WithValidator(validator Validator) Option {
 	return func(a *api) {
@@ -433,6 +681,79 @@ func WithValidator(validator Validator) Option {
 	}
 }
 
+// WithAuthorizer enables enforcement of route security requirements. Requests
+// to secured routes are rejected with 401 or 403 before the handler runs.
+// Without an authorizer, requirements are only stored in the request context
+// (see GetRouteSecurityContext) for custom middleware to enforce.
+func WithAuthorizer(authorizer Authorizer) Option {
+	return func(a *api) {
+		a.authorizer = authorizer
+	}
+}
+
+// WithPanicHandler sets the function called when an operation panics, e.g. to
+// report the panic to an error tracker. Panics are always recovered and turned
+// into a 500 Internal Server Error; without a handler they are logged.
+func WithPanicHandler(handler PanicHandler) Option {
+	return func(a *api) {
+		a.panicHandler = handler
+	}
+}
+
+// WithMarshalErrorHandler sets the function called when a response body cannot
+// be marshaled, e.g. to log or report the failure. Buffered responses are
+// replaced by a 500 Internal Server Error; without a handler failures are logged.
+func WithMarshalErrorHandler(handler MarshalErrorHandler) Option {
+	return func(a *api) {
+		a.marshalErrorHandler = handler
+	}
+}
+
+// WithCORS enables Cross-Origin Resource Sharing for all routes of the API.
+// Groups (see Group.UseCORS) and routes (see BaseRoute.CORS) can override it.
+func WithCORS(cors *CORS) Option {
+	return func(a *api) {
+		a.cors = cors
+	}
+}
+
+// WithCompression compresses response bodies with the content coding
+// negotiated from the Accept-Encoding header (zstd, br, gzip or deflate).
+// Responses smaller than Compression.MinSize, already encoded or with an
+// incompressible media type are sent as they are. Routes can opt out with
+// BaseRoute.DisableCompression.
+func WithCompression(compression *Compression) Option {
+	return func(a *api) {
+		a.compression = compression
+	}
+}
+
+// WithDocsUI configures the docs UI served at Config.DocsPath: the renderer
+// (Stoplight Elements, Swagger UI, Redoc or Scalar), title, logo, styles and
+// renderer options. The renderer assets are served from the binary. An unknown
+// renderer is logged and reported by the docs endpoint.
+func WithDocsUI(ui *DocsUI) Option {
+	return func(a *api) {
+		a.docsUI = ui
+		a.docsErr = nil
+		if ui != nil && ui.Renderer != "" && !slices.Contains(docsRenderers, ui.Renderer) {
+			a.docsErr = fmt.Errorf("unknown docs renderer %q", ui.Renderer)
+		}
+	}
+}
+
+// WithResponseValidation checks every response against the operation
+// documented in the OpenAPI spec, to catch contract drift in development and
+// staging. Status codes handlers respond with must be the route's DefaultStatus
+// or one of its Errors, and JSON bodies must match their schema.
+// Invalid responses are logged, and replaced by a 500 Internal Server Error in
+// ResponseValidationStrict mode. Routes with streaming bodies are not checked.
+func WithResponseValidation(mode ResponseValidationMode) Option {
+	return func(a *api) {
+		a.responseValidationMode = mode
+	}
+}
+
 // WithFormat adds a single format for content negotiation.
 // Multiple calls to WithFormat can be chained to add multiple formats.
 // Formats are merged with default formats, with later formats taking precedence.
@@ -488,6 +809,7 @@ func WithCodec(codec *schema.Codec) Option {
 }
 
 // WithDefaultFormat sets the default content type when Accept header is missing or no match is found.
+// It takes precedence over Config.DefaultFormat.
 func WithDefaultFormat(format string) Option {
 	return func(a *api) {
 		a.defaultFormat = format
@@ -513,7 +835,8 @@ func WithOpenAPI(openAPI *OpenAPI) Option {
 // output struct and an error. The input struct must be a struct with fields
 // for the request path/query/header/cookie parameters and/or body. The output
 // struct must be a struct with fields for the output headers and body of the
-// operation, if any.
+// operation, if any. Returns an error, without registering anything, if the
+// method is already registered for the path.
 
 func Register[I, O any](api API, route BaseRoute, handler func(context.Context, *I) (*O, error)) error {
 	inputType := reflect.TypeFor[I]()
@@ -525,43 +848,112 @@ func Register[I, O any](api API, route BaseRoute, handler func(context.Context,
 		return fmt.Errorf("output type %s must be a struct", outputType)
 	}
 
-	// Build and register OpenAPI operation immediately during route registration
-	op := buildOpenapiOperation(route.Method, route.Path, inputType, outputType, &route)
-	api.addOperationToState(op)
+	// Resolve group modifiers and check every resulting route before
+	// registering any of them
+	routes := api.resolveRoutes(&route)
+	for _, route := range routes {
+		if err := validateSecuritySchemes(api.OpenAPI(), route.Security); err != nil {
+			return err
+		}
+		if err := route.CORS.validate(); err != nil {
+			return err
+		}
+	}
 
-	// Create and register HTTP handler (routing logic remains unchanged)
-	httpHandler := createRequestHandler(api, &route, handler)
+	handlers := make([]http.Handler, len(routes))
+	for i, route := range routes {
+		route.eventStream = hasEventStreamBody(outputType)
+		route.content = hasContentBody(outputType)
+		route.inputType = inputType
+		route.outputType = outputType
+		route.middlewareCount = len(api.Middlewares()) + len(route.Middlewares)
+		route.describedBy = new(atomic.Pointer[string])
+
+		// Create HTTP handler
+		httpHandler := createRequestHandler(api, route, handler)
+
+		// Build middleware chain:
+		// 1. Panic recovery
+		// 2. CORS headers (if CORS is configured)
+		// 3. Response compression (if enabled with WithCompression)
+		// 4. Router params extraction
+		// 5. Security metadata middleware (if Secure() was used)
+		// 6. API-level middlewares
+		// 7. Authorization enforcement (if an Authorizer is configured)
+		// 8. Route-specific middlewares
+		// 9. Response validation (if enabled with WithResponseValidation)
+		allMiddlewares := Middlewares{newRecoverMiddleware(api)}
+		if corsMiddleware := newCORSMiddleware(route.CORS, outputHeaderNames(api, outputType)); corsMiddleware != nil {
+			allMiddlewares = append(allMiddlewares, corsMiddleware)
+		}
+		if compressionMiddleware := newCompressionMiddleware(api.compressionConfig(), route); compressionMiddleware != nil {
+			allMiddlewares = append(allMiddlewares, compressionMiddleware)
+		}
+		allMiddlewares = append(allMiddlewares, newRouterParamsMiddleware(api.Adapter(), route))
+		if securityMiddleware := newSecurityMetadataMiddleware(route.Security); securityMiddleware != nil {
+			allMiddlewares = append(allMiddlewares, securityMiddleware)
+		}
+		allMiddlewares = append(allMiddlewares, api.Middlewares()...)
+		if authzMiddleware := newAuthorizationMiddleware(api, route.Security); authzMiddleware != nil {
+			allMiddlewares = append(allMiddlewares, authzMiddleware)
+		}
+		allMiddlewares = append(allMiddlewares, route.Middlewares...)
+		if validationMiddleware := newResponseValidationMiddleware(api, route, outputType); validationMiddleware != nil {
+			allMiddlewares = append(allMiddlewares, validationMiddleware)
+		}
+
+		handlers[i] = allMiddlewares.Apply(http.HandlerFunc(httpHandler))
+	}
+
+	if err := api.registerRoutes(routes, handlers); err != nil {
+		return err
+	}
+
+	// Document the operations once their routes are registered
+	for _, route := range routes {
+		op := buildOpenapiOperation(route.Method, route.Path, inputType, outputType, route)
+		api.addOperationToState(op, route)
+	}
+
+	return nil
+}
 
-	// Build middleware chain:
-	// 1. Router params extraction
-	// 2. Security metadata middleware (if Secure() was used)
-	// 3. API-level middlewares
-	// 4. Route-specific middlewares
-	allMiddlewares := Middlewares{newRouterParamsMiddleware(api.Adapter(), &route)}
-	if securityMiddleware := newSecurityMetadataMiddleware(route.Security); securityMiddleware != nil {
-		allMiddlewares = append(allMiddlewares, securityMiddleware)
+// validateSecuritySchemes checks that every security scheme referenced by the
+// route's requirements is declared in the OpenAPI components.
+func validateSecuritySchemes(spec *OpenAPI, security *RouteSecurity) error {
+	if security == nil {
+		return nil
 	}
-	allMiddlewares = append(allMiddlewares, api.Middlewares()...)
-	allMiddlewares = append(allMiddlewares, route.Middlewares...)
-	finalHandler := allMiddlewares.Apply(http.HandlerFunc(httpHandler))
 
-	api.Adapter().Handle(&route, finalHandler.ServeHTTP)
+	for _, req := range security.Requirements {
+		for name := range req {
+			if spec.Components == nil || spec.Components.SecuritySchemes[name] == nil {
+				return fmt.Errorf("security scheme %q is not declared in OpenAPI components", name)
+			}
+		}
+	}
 
 	return nil
 }
 
 // createRequestHandler creates the HTTP handler for processing requests.
 func createRequestHandler[I, O any](api API, route *BaseRoute, handler func(context.Context, *I) (*O, error)) func(http.ResponseWriter, *http.Request) {
+	bodyIndex := structuredBodyField(reflect.TypeFor[I]())
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Router params are extracted by RouterParamsMiddleware and stored in context
 		routerParams := GetRouterParams(r)
 
 		// Setup request limits
-		setupRequestLimits(r, w, *route)
+		if err := setupRequestLimits(r, w, *route); err != nil {
+			WriteErr(api, r, w, 0, "", err)
+
+			return
+		}
 
 		// Decode and validate request
 		input := new(I)
-		if err := decodeAndValidateRequest(api, r, routerParams, input); err != nil {
+		if err := decodeAndValidateRequest(api, r, routerParams, input, bodyIndex); err != nil {
 			WriteErr(api, r, w, 0, "", err)
 
 			return
@@ -570,28 +962,48 @@ func createRequestHandler[I, O any](api API, route *BaseRoute, handler func(cont
 		// Execute handler
 		output, err := handler(r.Context(), input)
 		if err != nil {
+			_, status := processExistingError(err)
+			setHandlerStatus(r, status)
 			WriteErr(api, r, w, 0, "", err)
 
 			return
 		}
 
+		// Link the response to its JSON Schema
+		if link := route.describedByLink(); link != "" {
+			w.Header().Add("Link", link)
+		}
+
 		// Transform and write response
 		defaultStatus := route.DefaultStatus
 		if defaultStatus == 0 {
 			defaultStatus = http.StatusOK
 		}
-		if err := transformAndWriteResponse(api, r, w, output, defaultStatus); err != nil {
+		if err := transformAndWriteResponse(api, r, w, route, output, defaultStatus); err != nil {
 			return // Error already written
 		}
 	}
 }
 
 // decodeAndValidateRequest decodes and validates the request input.
-func decodeAndValidateRequest[I any](api API, r *http.Request, routerParams map[string]string, input *I) error {
-	if err := api.Codec().DecodeRequest(r, routerParams, input); err != nil {
+// Structured bodies in non-JSON media types are decoded with the matching Format.
+func decodeAndValidateRequest[I any](api API, r *http.Request, routerParams map[string]string, input *I, bodyIndex []int) error {
+	codecReq, body, err := decodeFormatBody(api, r, bodyIndex, reflect.TypeFor[I]())
+	if err != nil {
 		return err
 	}
 
+	if err := api.Codec().DecodeRequest(codecReq, routerParams, input); err != nil {
+		return err
+	}
+	if params, ok := any(input).(eventStreamInput); ok {
+		params.setEventStreamParams(r)
+	}
+
+	if body != nil {
+		reflect.ValueOf(input).Elem().FieldByIndex(bodyIndex).Set(*body)
+	}
+
 	if errs := validateRequest(api, r, input); len(errs) > 0 {
 		return NewError(http.StatusUnprocessableEntity, "validation failed", errs...)
 	}
@@ -600,7 +1012,7 @@ func decodeAndValidateRequest[I any](api API, r *http.Request, routerParams map[
 }
 
 // transformAndWriteResponse transforms the output and writes the response.
-func transformAndWriteResponse[O any](api API, r *http.Request, w http.ResponseWriter, output *O, defaultStatus int) error {
+func transformAndWriteResponse[O any](api API, r *http.Request, w http.ResponseWriter, route *BaseRoute, output *O, defaultStatus int) error {
 	statusCode := defaultStatus
 	transformed, err := api.Transform(r, statusCode, output)
 	if err != nil {
@@ -617,7 +1029,7 @@ func transformAndWriteResponse[O any](api API, r *http.Request, w http.ResponseW
 		return err
 	}
 
-	if err := writeResponse(api, r, w, transformedOutput, statusCode); err != nil {
+	if err := writeResponse(api, r, w, route, transformedOutput, statusCode); err != nil {
 		WriteErr(api, r, w, http.StatusInternalServerError, "failed to write response", err)
 
 		return err
@@ -661,14 +1073,23 @@ func Patch[I, O any](api API, path string, handler func(context.Context, *I) (*O
 	convenience(api, http.MethodPatch, path, handler, options...)
 }
 
-// Head registers a HEAD route handler.
+// Head registers a HEAD route handler. GET routes answer HEAD requests
+// automatically, so Head is only needed to handle them differently.
 // Panics on errors since route registration happens during startup
 // and errors represent programming/configuration mistakes.
 func Head[I, O any](api API, path string, handler func(context.Context, *I) (*O, error), options ...func(*BaseRoute)) {
 	convenience(api, http.MethodHead, path, handler, options...)
 }
 
-// convenience is a helper function used by Get, Post, Put, Delete, Patch, and Head.
+// Options registers an OPTIONS route handler. Paths without one answer OPTIONS
+// requests automatically with the allowed methods.
+// Panics on errors since route registration happens during startup
+// and errors represent programming/configuration mistakes.
+func Options[I, O any](api API, path string, handler func(context.Context, *I) (*O, error), options ...func(*BaseRoute)) {
+	convenience(api, http.MethodOptions, path, handler, options...)
+}
+
+// convenience is a helper function used by Get, Post, Put, Delete, Patch, Head and Options.
 // Panics on errors since route registration happens during startup and errors
 // represent programming/configuration mistakes that should fail fast.
 func convenience[I, O any](api API, method, path string, handler func(context.Context, *I) (*O, error), options ...func(o *BaseRoute)) {
@@ -1,6 +1,8 @@
 package zorya
 
 import (
+	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,6 +10,8 @@ import (
 
 const (
 	contentTypeJSON        = "application/json"
+	contentTypeXML         = "application/xml"
+	contentTypeProblemJSON = "application/problem+json"
 	contentTypeMultipart   = "multipart/form-data"
 	contentTypeOctetStream = "application/octet-stream"
 )
@@ -61,26 +65,26 @@ type ErrorDetailer interface {
 //nolint:errname // ErrorModel intentionally matches RFC 7807 Problem Details naming
 type ErrorModel struct {
 	// Type is a URI to get more information about the error type.
-	Type string `json:"type,omitempty"`
+	Type string `json:"type,omitempty" xml:"type,omitempty"`
 
 	// Title provides a short static summary of the problem. Zorya will default this
 	// to the HTTP response status code text if not present.
-	Title string `json:"title,omitempty"`
+	Title string `json:"title,omitempty" xml:"title,omitempty"`
 
 	// Status provides the HTTP status code for client convenience. Zorya will
 	// default this to the response status code if unset. This SHOULD match the
 	// response status code (though proxies may modify the actual status code).
-	Status int `json:"status,omitempty"`
+	Status int `json:"status,omitempty" xml:"status,omitempty"`
 
 	// Detail is an explanation specific to this error occurrence.
-	Detail string `json:"detail,omitempty"`
+	Detail string `json:"detail,omitempty" xml:"detail,omitempty"`
 
 	// Instance is a URI to get more info about this error occurrence.
-	Instance string `json:"instance,omitempty"`
+	Instance string `json:"instance,omitempty" xml:"instance,omitempty"`
 
 	// Errors provides an optional mechanism of passing additional error details
 	// as a list.
-	Errors []*ErrorDetail `json:"errors,omitempty"`
+	Errors []*ErrorDetail `json:"errors,omitempty" xml:"-"`
 }
 
 // ErrorDetail provides details about a specific error.
@@ -89,16 +93,16 @@ type ErrorModel struct {
 type ErrorDetail struct {
 	// Code is a machine-readable error code (e.g., "required", "email", "min").
 	// This enables frontend translation and automated error handling.
-	Code string `json:"code,omitempty"`
+	Code string `json:"code,omitempty" xml:"code,omitempty"`
 
 	// Message is a human-readable explanation of the error (optional).
 	// Useful for developers, logs, and debugging.
-	Message string `json:"message,omitempty"`
+	Message string `json:"message,omitempty" xml:"message,omitempty"`
 
 	// Location is a path-like string indicating where the error occurred.
 	// It typically begins with `path`, `query`, `header`, or `body`. Example:
 	// `body.items[3].tags` or `path.thing-id`.
-	Location string `json:"location,omitempty"`
+	Location string `json:"location,omitempty" xml:"location,omitempty"`
 }
 
 //nolint:errname // errWithHeaders is an internal wrapper, not a public error type
@@ -175,19 +179,40 @@ var NewError = func(status int, msg string, errs ...error) StatusError {
 // Otherwise, a new error is created using NewError(status, msg, errs...).
 // The error is marshaled using the API's content negotiation methods.
 func WriteErr(api API, r *http.Request, w http.ResponseWriter, status int, msg string, errs ...error) {
+	// Headers are read from the error passed in, as converting it to a
+	// StatusError drops wrappers such as the one of ErrorWithHeaders
+	var headersErr error
+	if status == 0 && msg == "" && len(errs) > 0 && errs[0] != nil {
+		headersErr = errs[0]
+	}
+
 	// Determine the error to write and its status
 	errToWrite, status := determineErrorToWrite(status, msg, errs)
+	if headersErr == nil {
+		headersErr = errToWrite
+	}
 
 	// Set headers if error implements HeadersError
-	applyErrorHeaders(w, errToWrite)
+	applyErrorHeaders(w, headersErr)
 
-	// Negotiate and set content type
+	// Negotiate content type and marshal the error before writing the status
 	ct := negotiateContentType(api, r, errToWrite)
+	var buf bytes.Buffer
+	if err := api.Marshal(&buf, ct, errToWrite); err != nil {
+		api.reportMarshalError(r, ct, err)
+
+		// Fall back to JSON, which can encode any error model
+		buf.Reset()
+		ct = contentTypeJSON
+		if ctp, ok := errToWrite.(ContentTypeProvider); ok {
+			ct = ctp.ContentType(ct)
+		}
+		_ = JSONFormat().Marshal(&buf, errToWrite)
+	}
+
 	w.Header().Set("Content-Type", ct)
 	w.WriteHeader(status)
-
-	// Marshal and write error (fallback handled internally by Marshal)
-	api.Marshal(w, ct, errToWrite)
+	_, _ = w.Write(buf.Bytes())
 }
 
 // determineErrorToWrite determines the error to write and its status code.
@@ -231,9 +256,9 @@ func processExistingError(err error) (StatusError, int) {
 }
 
 // applyErrorHeaders sets headers from HeadersError if present.
-func applyErrorHeaders(w http.ResponseWriter, errToWrite StatusError) {
+func applyErrorHeaders(w http.ResponseWriter, err error) {
 	var he HeadersError
-	if errors.As(errToWrite, &he) {
+	if errors.As(err, &he) {
 		for k, values := range he.GetHeaders() {
 			for _, v := range values {
 				w.Header().Add(k, v)
@@ -408,15 +433,43 @@ func (e *ErrorModel) GetStatus() int {
 // RFC 9457 Problem Details for HTTP APIs are used in responses to clients.
 func (e *ErrorModel) ContentType(ct string) string {
 	if ct == contentTypeJSON {
-		return "application/problem+json"
+		return contentTypeProblemJSON
 	}
 	if ct == "application/cbor" {
 		return "application/problem+cbor"
 	}
+	if ct == contentTypeXML {
+		return "application/problem+xml"
+	}
 
 	return ct
 }
 
+// MarshalXML encodes the error as an RFC 9457 problem details XML document.
+// Error details are listed as <i> elements of an <errors> element, which is
+// left out when there are none.
+func (e *ErrorModel) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type problem ErrorModel
+	start.Name = xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}
+
+	// omitempty does not apply to an "errors>i" path, which always writes
+	// the <errors> element
+	var errs *xmlErrorDetails
+	if len(e.Errors) > 0 {
+		errs = &xmlErrorDetails{Items: e.Errors}
+	}
+
+	return enc.EncodeElement(struct {
+		*problem
+		Errors *xmlErrorDetails `xml:"errors,omitempty"`
+	}{(*problem)(e), errs}, start)
+}
+
+// xmlErrorDetails is the <errors> element of a problem details XML document.
+type xmlErrorDetails struct {
+	Items []*ErrorDetail `xml:"i"`
+}
+
 // Error returns the error message / satisfies the `error` interface.
 func (e *ErrorDetail) Error() string {
 	if e.Message != "" {
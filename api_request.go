@@ -1,13 +1,24 @@
 package zorya
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"reflect"
+	"strings"
 	"time"
+
+	"github.com/talav/schema"
 )
 
-// setupRequestLimits configures body read timeout and size limits for the request.
-func setupRequestLimits(r *http.Request, w http.ResponseWriter, route BaseRoute) {
+// setupRequestLimits configures body read timeout and size limits for the
+// request. Compressed bodies are decompressed first, so that the size limit
+// applies to the decompressed body. Returns a 415 error for unsupported
+// content codings.
+func setupRequestLimits(r *http.Request, w http.ResponseWriter, route BaseRoute) error {
 	// Apply body read timeout.
 	// This sets a deadline for reading the request body, helping prevent slow-loris attacks.
 	// Default is 5 seconds if not explicitly configured.
@@ -25,6 +36,10 @@ func setupRequestLimits(r *http.Request, w http.ResponseWriter, route BaseRoute)
 		}
 	}
 
+	if err := decompressRequestBody(r); err != nil {
+		return err
+	}
+
 	// Apply body size limit using http.MaxBytesReader.
 	// Default to 1MB if not explicitly configured.
 	maxBytes := route.MaxBodyBytes
@@ -34,6 +49,8 @@ func setupRequestLimits(r *http.Request, w http.ResponseWriter, route BaseRoute)
 	if maxBytes > 0 {
 		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 	}
+
+	return nil
 }
 
 // validateRequest validates the decoded input struct.
@@ -54,3 +71,186 @@ func validateRequest[I any](api API, r *http.Request, input *I) []error {
 
 	return v.Validate(r.Context(), input, metadata)
 }
+
+// structuredBodyField returns the index of the input field tagged
+// `body:"structured"`, or nil if the input has no structured body.
+func structuredBodyField(typ reflect.Type) []int {
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if mode, _, _ := strings.Cut(field.Tag.Get("body"), ","); mode == "structured" {
+			return field.Index
+		}
+	}
+
+	return nil
+}
+
+// decodeFormatBody decodes a structured request body sent in a non-JSON media
+// type with the format registered for its Content-Type. JSON, form and
+// multipart bodies are left to the codec, and so are XML bodies when no format
+// is registered for them. It returns the request to pass to the codec, whose
+// body is replaced by an empty JSON placeholder when the format decoded it, and
+// the decoded body to set on the input after the codec ran, or nil if there is
+// none.
+func decodeFormatBody(api API, r *http.Request, bodyIndex []int, inputType reflect.Type) (*http.Request, *reflect.Value, error) {
+	header := r.Header.Get("Content-Type")
+	if bodyIndex == nil || header == "" {
+		return r, nil, nil
+	}
+
+	ct, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return nil, nil, Error415UnsupportedMediaType(fmt.Sprintf("invalid Content-Type %q", header), err)
+	}
+	if decodedByCodec(ct) {
+		return r, nil, nil
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fieldType := inputType.FieldByIndex(bodyIndex).Type
+	body := reflect.New(fieldType)
+	if len(data) > 0 {
+		if err := api.Unmarshal(ct, data, body.Interface()); err != nil {
+			var statusErr StatusError
+			if isXMLMediaType(ct) && errors.As(err, &statusErr) && statusErr.GetStatus() == http.StatusUnsupportedMediaType {
+				// Without an XML format, the codec decodes XML bodies itself
+				r.Body = io.NopCloser(bytes.NewReader(data))
+
+				return r, nil, nil
+			}
+
+			return nil, nil, err
+		}
+	}
+
+	// Let the codec decode parameters from a request with an empty JSON body
+	// of the matching shape.
+	placeholder := "null"
+	switch fieldType.Kind() {
+	case reflect.Struct, reflect.Map:
+		placeholder = "{}"
+	case reflect.Slice, reflect.Array:
+		placeholder = "[]"
+	default:
+	}
+
+	codecReq := r.Clone(r.Context())
+	codecReq.Header.Set("Content-Type", contentTypeJSON)
+	codecReq.Body = io.NopCloser(strings.NewReader(placeholder))
+	codecReq.ContentLength = int64(len(placeholder))
+
+	if len(data) == 0 {
+		return codecReq, nil, nil
+	}
+
+	value := body.Elem()
+
+	return codecReq, &value, nil
+}
+
+// decodedByCodec reports whether structured bodies in the media type are always
+// decoded by the codec: JSON, URL-encoded forms and multipart forms.
+func decodedByCodec(ct string) bool {
+	return ct == contentTypeJSON || strings.HasSuffix(ct, "+json") ||
+		ct == "application/x-www-form-urlencoded" || strings.HasPrefix(ct, "multipart/")
+}
+
+// isXMLMediaType reports whether the codec can decode bodies in the media type
+// as XML.
+func isXMLMediaType(ct string) bool {
+	return ct == "application/xml" || ct == "text/xml"
+}
+
+// convertTime converts parameter values to time.Time. Values are accepted in
+// RFC 3339 and in the HTTP date formats used by headers like If-Modified-Since.
+func convertTime(value any) (reflect.Value, error) {
+	s, ok := value.(string)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("cannot convert %T to time.Time", value)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		if t, err = http.ParseTime(s); err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid time %q", s)
+		}
+	}
+
+	return reflect.ValueOf(t), nil
+}
+
+// paramDecoder decodes cookie parameters in the form style, which the wrapped
+// decoder rejects for single values, and leaves the other parameters to it.
+// Repeated cookies and comma-separated values decode into slices. Empty header
+// and cookie values are left unset, like absent ones.
+type paramDecoder struct {
+	schema.Decoder
+}
+
+// Decode implements schema.Decoder.
+func (d paramDecoder) Decode(r *http.Request, routerParams map[string]string, metadata *schema.StructMetadata) (map[string]any, error) {
+	withoutCookies := r
+	if len(r.Header.Values("Cookie")) > 0 {
+		withoutCookies = r.Clone(r.Context())
+		withoutCookies.Header.Del("Cookie")
+	}
+
+	result, err := d.Decoder.Decode(withoutCookies, routerParams, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range metadata.Fields {
+		field := &metadata.Fields[i]
+		schemaMeta, ok := schema.GetTagMetadata[*schema.SchemaMetadata](field, "schema")
+		if !ok {
+			continue
+		}
+
+		switch schemaMeta.Location {
+		case schema.LocationHeader:
+			// The wrapped decoder decodes absent headers as empty strings
+			if r.Header.Get(schemaMeta.ParamName) == "" {
+				delete(result, schemaMeta.ParamName)
+			}
+		case schema.LocationCookie:
+			if value := cookieParam(r, schemaMeta.ParamName, field.Type); value != nil {
+				result[schemaMeta.ParamName] = value
+			}
+		default:
+		}
+	}
+
+	return result, nil
+}
+
+// cookieParam returns the value of the cookie parameter: the first cookie with
+// the name, or all of their comma-separated values for slices. Returns nil if
+// there is no value.
+func cookieParam(r *http.Request, name string, typ reflect.Type) any {
+	var values []any
+	for _, cookie := range r.CookiesNamed(name) {
+		if cookie.Value == "" {
+			continue
+		}
+		if typ.Kind() != reflect.Slice {
+			return cookie.Value
+		}
+		for value := range strings.SplitSeq(cookie.Value, ",") {
+			values = append(values, value)
+		}
+	}
+	if values == nil {
+		return nil
+	}
+
+	return values
+}
@@ -0,0 +1,88 @@
+package zorya
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type paramsInput struct {
+	Session  string     `schema:"session,location=cookie"`
+	Visits   int        `schema:"visits,location=cookie"`
+	Flags    []string   `schema:"flag,location=cookie"`
+	Since    time.Time  `schema:"If-Modified-Since,location=header"`
+	Until    *time.Time `schema:"X-Until,location=header"`
+	Tenant   string     `schema:"X-Tenant,location=header" default:"public"`
+	Deadline time.Time  `schema:"deadline,location=query"`
+}
+
+type paramsOutput struct {
+	Body paramsInput `body:"structured"`
+}
+
+func TestParamDecoding(t *testing.T) {
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router})
+	Get(api, "/params", func(ctx context.Context, input *paramsInput) (*paramsOutput, error) {
+		return &paramsOutput{Body: *input}, nil
+	})
+
+	decode := func(t *testing.T, req *http.Request) paramsInput {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		var out paramsInput
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &out))
+
+		return out
+	}
+
+	t.Run("cookies", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/params", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+		req.AddCookie(&http.Cookie{Name: "visits", Value: "4"})
+		req.AddCookie(&http.Cookie{Name: "flag", Value: "a"})
+		req.AddCookie(&http.Cookie{Name: "flag", Value: "b,c"})
+
+		out := decode(t, req)
+		assert.Equal(t, "s1", out.Session)
+		assert.Equal(t, 4, out.Visits)
+		assert.Equal(t, []string{"a", "b", "c"}, out.Flags)
+	})
+
+	t.Run("times", func(t *testing.T) {
+		since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		req := httptest.NewRequest(http.MethodGet, "/params?deadline=2024-01-02T03:04:05.5Z", nil)
+		req.Header.Set("If-Modified-Since", since.Format(http.TimeFormat))
+		req.Header.Set("X-Until", since.Format(time.RFC3339))
+
+		out := decode(t, req)
+		assert.True(t, since.Equal(out.Since), "HTTP dates are decoded")
+		require.NotNil(t, out.Until)
+		assert.True(t, since.Equal(*out.Until), "RFC 3339 headers are decoded")
+		assert.True(t, since.Add(500*time.Millisecond).Equal(out.Deadline))
+	})
+
+	t.Run("empty values are unset", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/params", nil)
+		req.Header.Set("X-Until", "")
+		req.AddCookie(&http.Cookie{Name: "session", Value: ""})
+
+		out := decode(t, req)
+		assert.Empty(t, out.Session)
+		assert.Nil(t, out.Flags)
+		assert.True(t, out.Since.IsZero())
+		assert.Nil(t, out.Until, "empty headers do not set a zero time")
+		assert.Equal(t, "public", out.Tenant, "defaults apply to absent headers")
+	})
+}
@@ -1,9 +1,13 @@
 package zorya
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/talav/schema"
 )
@@ -16,7 +20,7 @@ type StatusProvider interface {
 }
 
 // writeResponse writes the HTTP response.
-func writeResponse[O any](api API, r *http.Request, w http.ResponseWriter, output *O, statusCode int) error {
+func writeResponse[O any](api API, r *http.Request, w http.ResponseWriter, route *BaseRoute, output *O, statusCode int) error {
 	vo := reflect.ValueOf(output).Elem()
 
 	// Get struct metadata for response handling
@@ -28,6 +32,7 @@ func writeResponse[O any](api API, r *http.Request, w http.ResponseWriter, outpu
 
 	// Extract and write headers
 	writeHeaders(w, structMeta, vo)
+	writeValidators(w, output)
 
 	// Check if output type implements StatusProvider interface.
 	statusProviderType := reflect.TypeOf((*StatusProvider)(nil)).Elem()
@@ -36,6 +41,7 @@ func writeResponse[O any](api API, r *http.Request, w http.ResponseWriter, outpu
 			statusCode = sp.Status()
 		}
 	}
+	setHandlerStatus(r, statusCode)
 
 	// Find body field by checking for "body" tag
 	bodyFieldMeta := FindBodyField(structMeta)
@@ -47,7 +53,7 @@ func writeResponse[O any](api API, r *http.Request, w http.ResponseWriter, outpu
 	}
 
 	// Extract and write body.
-	writeBody(api, r, w, vo, bodyFieldMeta, statusCode)
+	writeBody(api, r, w, route, vo, bodyFieldMeta, statusCode)
 
 	return nil
 }
@@ -97,8 +103,28 @@ func writeHeaders(w http.ResponseWriter, structMeta *schema.StructMetadata, vo r
 	}
 }
 
+// writeValidators sets the ETag and Last-Modified headers from an output
+// implementing ETag() string or LastModified() time.Time (see
+// conditional.ETagger and conditional.LastModifier). Unquoted ETags are quoted.
+func writeValidators(w http.ResponseWriter, output any) {
+	if etagger, ok := output.(interface{ ETag() string }); ok {
+		if etag := etagger.ETag(); etag != "" {
+			if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+				etag = `"` + etag + `"`
+			}
+			w.Header().Set("ETag", etag)
+		}
+	}
+
+	if modifier, ok := output.(interface{ LastModified() time.Time }); ok {
+		if modified := modifier.LastModified(); !modified.IsZero() {
+			w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+		}
+	}
+}
+
 // writeBody handles body extraction and writing.
-func writeBody(api API, r *http.Request, w http.ResponseWriter, vo reflect.Value, bodyFieldMeta *schema.FieldMetadata, status int) {
+func writeBody(api API, r *http.Request, w http.ResponseWriter, route *BaseRoute, vo reflect.Value, bodyFieldMeta *schema.FieldMetadata, status int) {
 	bodyField := vo.Field(bodyFieldMeta.Index)
 	if !bodyField.IsValid() {
 		w.WriteHeader(status)
@@ -115,6 +141,25 @@ func writeBody(api API, r *http.Request, w http.ResponseWriter, vo reflect.Value
 
 	body := bodyField.Interface()
 
+	// Handle Server-Sent Events streams.
+	if stream, ok := body.(EventStream); ok {
+		if stream == nil {
+			w.WriteHeader(status)
+
+			return
+		}
+		writeEventStream(api, r, w, route, stream, status)
+
+		return
+	}
+
+	// Handle seekable content with Range support.
+	if content, ok := body.(Content); ok {
+		writeContent(api, r, w, content, status)
+
+		return
+	}
+
 	// Handle []byte (raw bytes) - no content negotiation.
 	if b, ok := body.([]byte); ok {
 		writeRawBody(w, status, b)
@@ -122,7 +167,7 @@ func writeBody(api API, r *http.Request, w http.ResponseWriter, vo reflect.Value
 		return
 	}
 
-	writeNegotiatedBody(api, r, w, status, body)
+	writeNegotiatedBody(api, r, w, status, body, route.UnbufferedResponse)
 }
 
 // writeBodyFunc executes a body callback function for streaming responses.
@@ -139,7 +184,7 @@ func writeBodyFunc(api API, w http.ResponseWriter, r *http.Request, bodyField re
 	}
 }
 
-// writeHeaderTracker wraps http.ResponseWriter to detect if WriteHeader was called.
+// writeHeaderTracker wraps http.ResponseWriter to detect if the response was started.
 type writeHeaderTracker struct {
 	http.ResponseWriter
 	written bool
@@ -150,14 +195,27 @@ func (t *writeHeaderTracker) WriteHeader(code int) {
 	t.ResponseWriter.WriteHeader(code)
 }
 
+func (t *writeHeaderTracker) Write(b []byte) (int, error) {
+	t.written = true
+
+	return t.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (t *writeHeaderTracker) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
 // writeRawBody writes raw bytes without content negotiation.
 func writeRawBody(w http.ResponseWriter, status int, data []byte) {
 	w.WriteHeader(status)
 	_, _ = w.Write(data)
 }
 
-// writeNegotiatedBody negotiates content type and marshals the body.
-func writeNegotiatedBody(api API, r *http.Request, w http.ResponseWriter, status int, body any) {
+// writeNegotiatedBody negotiates content type and marshals the body. The body is
+// marshaled into a buffer first so that a marshaling failure results in a 500
+// error instead of a truncated response, unless unbuffered is set.
+func writeNegotiatedBody(api API, r *http.Request, w http.ResponseWriter, status int, body any, unbuffered bool) {
 	var ct string
 	var err error
 	// Check if body implements ContentTypeProvider (e.g., ErrorModel).
@@ -165,6 +223,12 @@ func writeNegotiatedBody(api API, r *http.Request, w http.ResponseWriter, status
 		ct = ctp.ContentType(ct)
 	} else {
 		ct, err = api.Negotiate(r.Header.Get("Accept"))
+		var se StatusError
+		if errors.As(err, &se) {
+			WriteErr(api, r, w, 0, "", se)
+
+			return
+		}
 		if err != nil {
 			WriteErr(api, r, w, http.StatusNotAcceptable, "Not Acceptable", err)
 
@@ -172,11 +236,29 @@ func writeNegotiatedBody(api API, r *http.Request, w http.ResponseWriter, status
 		}
 	}
 
+	if unbuffered {
+		w.Header().Set("Content-Type", ct)
+		w.WriteHeader(status)
+
+		// The status is already sent, a failure can only be reported
+		if err := api.Marshal(w, ct, body); err != nil {
+			api.reportMarshalError(r, ct, err)
+		}
+
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := api.Marshal(&buf, ct, body); err != nil {
+		api.reportMarshalError(r, ct, err)
+		WriteErr(api, r, w, http.StatusInternalServerError, "failed to marshal response", err)
+
+		return
+	}
+
 	w.Header().Set("Content-Type", ct)
 	w.WriteHeader(status)
-
-	// Marshal body (fallback handled internally by Marshal)
-	api.Marshal(w, ct, body)
+	_, _ = w.Write(buf.Bytes())
 }
 
 // formatHeaderValue converts a reflect.Value to a string suitable for use as a header value.
@@ -1,12 +1,20 @@
 package zorya
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -170,6 +178,11 @@ func TestOpenAPIEndpoint_EndToEnd(t *testing.T) {
         "responses": {
           "200": {
             "content": {
+              "application/cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/GetUserOutputBody"
+                }
+              },
               "application/json": {
                 "schema": {
                   "$ref": "#/components/schemas/GetUserOutputBody"
@@ -180,6 +193,11 @@ func TestOpenAPIEndpoint_EndToEnd(t *testing.T) {
           },
           "422": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -190,6 +208,11 @@ func TestOpenAPIEndpoint_EndToEnd(t *testing.T) {
           },
           "500": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -393,6 +416,11 @@ func TestOpenAPIEndpoint_FileUpload(t *testing.T) {
         "responses": {
           "200": {
             "content": {
+              "application/cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/UploadFileOutputBody"
+                }
+              },
               "application/json": {
                 "schema": {
                   "$ref": "#/components/schemas/UploadFileOutputBody"
@@ -403,6 +431,11 @@ func TestOpenAPIEndpoint_FileUpload(t *testing.T) {
           },
           "422": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -413,6 +446,11 @@ func TestOpenAPIEndpoint_FileUpload(t *testing.T) {
           },
           "500": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -573,6 +611,11 @@ func TestOpenAPIEndpoint_FileDownload(t *testing.T) {
           },
           "422": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -583,6 +626,11 @@ func TestOpenAPIEndpoint_FileDownload(t *testing.T) {
           },
           "500": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -750,6 +798,11 @@ func TestOpenAPIEndpoint_FileInResponse(t *testing.T) {
         "responses": {
           "200": {
             "content": {
+              "application/cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/GetFileWithMetadataOutputBody"
+                }
+              },
               "application/json": {
                 "schema": {
                   "$ref": "#/components/schemas/GetFileWithMetadataOutputBody"
@@ -760,6 +813,11 @@ func TestOpenAPIEndpoint_FileInResponse(t *testing.T) {
           },
           "422": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -770,6 +828,11 @@ func TestOpenAPIEndpoint_FileInResponse(t *testing.T) {
           },
           "500": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -1147,6 +1210,11 @@ func TestOpenAPIEndpoint_ComprehensiveValidations(t *testing.T) {
         ],
         "requestBody": {
           "content": {
+            "application/cbor": {
+              "schema": {
+                "$ref": "#/components/schemas/ComprehensiveValidationBody"
+              }
+            },
             "application/json": {
               "schema": {
                 "$ref": "#/components/schemas/ComprehensiveValidationBody"
@@ -1158,6 +1226,11 @@ func TestOpenAPIEndpoint_ComprehensiveValidations(t *testing.T) {
         "responses": {
           "200": {
             "content": {
+              "application/cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ComprehensiveValidationOutputBody"
+                }
+              },
               "application/json": {
                 "schema": {
                   "$ref": "#/components/schemas/ComprehensiveValidationOutputBody"
@@ -1168,6 +1241,11 @@ func TestOpenAPIEndpoint_ComprehensiveValidations(t *testing.T) {
           },
           "422": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -1178,6 +1256,11 @@ func TestOpenAPIEndpoint_ComprehensiveValidations(t *testing.T) {
           },
           "500": {
             "content": {
+              "application/problem+cbor": {
+                "schema": {
+                  "$ref": "#/components/schemas/ErrorModel"
+                }
+              },
               "application/problem+json": {
                 "schema": {
                   "$ref": "#/components/schemas/ErrorModel"
@@ -1196,6 +1279,64 @@ func TestOpenAPIEndpoint_ComprehensiveValidations(t *testing.T) {
 	assert.JSONEq(t, wantedJSON, string(actualJSON), "Generated JSON should match expected JSON")
 }
 
+func TestOpenAPIEndpoint_YAMLAndOpenAPI30(t *testing.T) {
+	type PetInput struct {
+		ID int `schema:"id,location=path,required=true"`
+	}
+	type PetOutput struct {
+		Body struct {
+			Name  string  `json:"name"`
+			Owner *string `json:"owner"`
+		} `body:"structured"`
+	}
+
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router})
+	Get(api, "/pets/{id}", func(ctx context.Context, input *PetInput) (*PetOutput, error) {
+		return &PetOutput{}, nil
+	})
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code, path)
+
+		return recorder
+	}
+
+	jsonSpec := get("/openapi.json", "")
+	assert.Equal(t, "application/vnd.oai.openapi+json", jsonSpec.Header().Get("Content-Type"))
+
+	yamlSpec := get("/openapi.yaml", "")
+	assert.Equal(t, "application/vnd.oai.openapi", yamlSpec.Header().Get("Content-Type"))
+	assert.Contains(t, yamlSpec.Body.String(), "openapi: 3.1.2\n")
+	assert.Contains(t, yamlSpec.Body.String(), "/pets/{id}:\n")
+	assert.NotEqual(t, jsonSpec.Header().Get("ETag"), yamlSpec.Header().Get("ETag"))
+
+	negotiated := get("/openapi.json", "application/yaml")
+	assert.Equal(t, yamlSpec.Body.String(), negotiated.Body.String(), "YAML must be served for Accept: application/yaml")
+	assert.Equal(t, yamlSpec.Header().Get("ETag"), negotiated.Header().Get("ETag"))
+	assert.Equal(t, "Accept", negotiated.Header().Get("Vary"))
+
+	spec30 := get("/openapi-3.0.json", "")
+	assert.Equal(t, "application/vnd.oai.openapi+json", spec30.Header().Get("Content-Type"))
+	assert.Contains(t, spec30.Body.String(), `"openapi":"3.0.3"`)
+	assert.NotContains(t, spec30.Body.String(), `"type":[`, "type arrays must be converted")
+
+	assert.Contains(t, get("/openapi-3.0.yaml", "").Body.String(), "openapi: 3.0.3\n")
+
+	// The cached variants share the spec ETag handling
+	req := httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil)
+	req.Header.Set("If-None-Match", yamlSpec.Header().Get("ETag"))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
+
 func TestDocsEndpoint_EndToEnd(t *testing.T) {
 	// 1. Setup: Create router, adapter, and API
 	router := chi.NewMux()
@@ -1280,3 +1421,668 @@ func TestStreamingBodyFunc(t *testing.T) {
 	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
 	assert.Contains(t, recorder.Body.String(), "data: hello")
 }
+
+func TestOpenAPIEndpoint_VariantsWhileAddingOperations(t *testing.T) {
+	type PetOutput struct {
+		Body struct {
+			Name string `json:"name"`
+		} `body:"structured"`
+	}
+
+	a := NewAPI(&testChiAdapter{router: chi.NewMux()}).(*api)
+	Get(a, "/pets", func(ctx context.Context, _ *struct{}) (*PetOutput, error) {
+		return &PetOutput{}, nil
+	})
+	route := &BaseRoute{Method: http.MethodGet, Path: "/pets"}
+	op := buildOpenapiOperation(route.Method, route.Path, nil, reflect.TypeFor[PetOutput](), route)
+
+	// Adding operations invalidates the cache while variants are generated
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			a.openapiState.AddOperation(op, route)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			data, _, err := a.openapiState.GenerateSpecFormat(context.Background(), specFormat{yaml: true})
+			assert.NoError(t, err)
+			assert.NotEmpty(t, data)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestOpenAPIEndpoint_SecurityRequirements(t *testing.T) {
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithOpenAPI(&OpenAPI{
+		Info: &Info{Title: "API", Version: "1.0.0"},
+		Components: &Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+				"apiKey":     {Type: "apiKey", Name: "X-API-Key", In: "header"},
+				"mtls":       {Type: "mutualTLS"},
+				"oauth2": {
+					Type: "oauth2",
+					Flows: &OAuthFlows{
+						AuthorizationCode: &OAuthFlow{
+							AuthorizationURL: "https://auth.example.com/authorize",
+							TokenURL:         "https://auth.example.com/token",
+							Scopes:           map[string]string{"admin": "Administer the API"},
+						},
+					},
+				},
+				"oidc": {Type: "openIdConnect", OpenIDConnectURL: "https://auth.example.com/.well-known/openid-configuration"},
+			},
+		},
+	}))
+
+	handler := func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		return &GetUserOutput{}, nil
+	}
+
+	Get(api, "/users/{id}", handler, Secure(
+		Roles("admin"),
+		SecuritySchemes("oauth2"),
+		SecuritySchemes("apiKey", "mtls"),
+	))
+
+	grp := NewGroup(api, "/v1")
+	grp.UseSecuritySchemes("oidc")
+	grp.UseRoles("admin")
+	Get(grp, "/users/{id}", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec struct {
+		Components struct {
+			SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+		} `json:"components"`
+		Paths map[string]map[string]struct {
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+
+	assert.Len(t, spec.Components.SecuritySchemes, 5)
+	assert.JSONEq(t, `{
+		"type": "oauth2",
+		"flows": {
+			"authorizationCode": {
+				"authorizationUrl": "https://auth.example.com/authorize",
+				"tokenUrl": "https://auth.example.com/token",
+				"scopes": {"admin": "Administer the API"}
+			}
+		}
+	}`, string(spec.Components.SecuritySchemes["oauth2"]))
+	assert.JSONEq(t, `{"type": "mutualTLS"}`, string(spec.Components.SecuritySchemes["mtls"]))
+
+	assert.Equal(t, []map[string][]string{
+		{"oauth2": {"admin"}},
+		{"apiKey": {}, "mtls": {}},
+	}, spec.Paths["/users/{id}"]["get"].Security)
+	assert.Equal(t, []map[string][]string{
+		{"oidc": {"admin"}},
+	}, spec.Paths["/v1/users/{id}"]["get"].Security)
+}
+
+func TestRegister_UndeclaredSecurityScheme(t *testing.T) {
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router})
+
+	err := Register(api, BaseRoute{
+		Method:   http.MethodGet,
+		Path:     "/users/{id}",
+		Security: &RouteSecurity{Requirements: []SecurityRequirement{{"bearerAuth": nil}}},
+	}, func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		return &GetUserOutput{}, nil
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"bearerAuth"`)
+
+	// Neither served nor documented
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Empty(t, api.Routes())
+
+	spec, err := api.Spec(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, string(spec), "/users/{id}")
+}
+
+func TestOpenAPIEndpoint_OperationOverrides(t *testing.T) {
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter)
+
+	Get(api, "/users/{id}", func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		return &GetUserOutput{}, nil
+	}, func(r *BaseRoute) {
+		r.Operation = &Operation{
+			Summary:      "Get a user",
+			ExternalDocs: &ExternalDocs{URL: "https://docs.example.com/users"},
+			Parameters: []*Param{
+				{Name: "id", In: "path", Description: "User ID"},
+				{Name: "X-Request-ID", In: "header", Schema: &Schema{Type: TypeString}},
+			},
+			Responses: map[string]*Response{
+				"200": {Headers: map[string]*Header{"X-Total": {Schema: &Schema{Type: TypeInteger}}}},
+				"404": {Description: "User not found"},
+			},
+			Servers:    []*Server{{URL: "https://users.example.com"}},
+			Extensions: map[string]any{"x-internal": true},
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec struct {
+		Paths map[string]map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	op := spec.Paths["/users/{id}"]["get"]
+
+	assert.JSONEq(t, `"Get a user"`, string(op["summary"]))
+	assert.JSONEq(t, `{"url": "https://docs.example.com/users"}`, string(op["externalDocs"]))
+	assert.JSONEq(t, `[{"url": "https://users.example.com"}]`, string(op["servers"]))
+	assert.JSONEq(t, `true`, string(op["x-internal"]))
+	assert.JSONEq(t, `[
+		{
+			"in": "path",
+			"name": "id",
+			"description": "User ID",
+			"required": true,
+			"schema": {"format": "int64", "type": "integer"},
+			"style": "simple"
+		},
+		{"in": "header", "name": "X-Request-ID", "schema": {"type": "string"}}
+	]`, string(op["parameters"]))
+
+	var responses map[string]map[string]any
+	require.NoError(t, json.Unmarshal(op["responses"], &responses))
+	assert.Equal(t, "OK", responses["200"]["description"], "reflected description is kept")
+	assert.Contains(t, responses["200"], "content", "reflected content is kept")
+	assert.Contains(t, responses["200"], "headers")
+	assert.Equal(t, "User not found", responses["404"]["description"])
+}
+
+func TestOpenAPIEndpoint_KeyOrder(t *testing.T) {
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router})
+
+	handler := func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		return &GetUserOutput{}, nil
+	}
+	summary := func(r *BaseRoute) {
+		r.Operation = &Operation{Summary: "Get a user", Tags: []string{"users"}}
+	}
+	Get(api, "/users/{id}", handler, summary)
+	require.NoError(t, Register(api, BaseRoute{Method: http.MethodTrace, Path: "/users/{id}", Operation: &Operation{Summary: "Trace a user"}}, handler))
+
+	spec, err := api.Spec(context.Background())
+	require.NoError(t, err)
+
+	// The generator's order is kept, with overrides in place
+	assert.Equal(t, []string{"openapi", "info", "paths", "components"}, objectKeys(t, spec))
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	assert.Equal(t, []string{"tags", "summary", "parameters", "responses"}, objectKeys(t, doc.Paths["/users/{id}"]["get"]))
+	assert.Equal(t, []string{"summary", "parameters", "responses"}, objectKeys(t, doc.Paths["/users/{id}"]["trace"]))
+}
+
+// objectKeys returns the keys of a JSON object in document order.
+func objectKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	_, err := dec.Token()
+	require.NoError(t, err)
+
+	var keys []string
+	for dec.More() {
+		token, err := dec.Token()
+		require.NoError(t, err)
+		keys = append(keys, token.(string))
+
+		var value json.RawMessage
+		require.NoError(t, dec.Decode(&value))
+	}
+
+	return keys
+}
+
+func TestSpecGenerationFailure(t *testing.T) {
+	type CreateInput struct {
+		Body struct {
+			Name string `json:"name"`
+		} `body:"structured"`
+	}
+	type CreateOutput struct {
+		Body struct {
+			Name string `json:"name"`
+		} `body:"structured"`
+	}
+
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router})
+
+	// The generator cannot name the bodies once the types are used twice
+	create := func(ctx context.Context, input *CreateInput) (*CreateOutput, error) {
+		return &CreateOutput{}, nil
+	}
+	Post(api, "/items", create)
+	Post(api, "/archived", create)
+
+	_, err := api.Spec(context.Background())
+	require.ErrorContains(t, err, "duplicate name")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	// Operations are served regardless
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestContentNegotiation_DefaultFormatAndStrictMode(t *testing.T) {
+	handler := func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		output := &GetUserOutput{}
+		output.Body.ID = input.ID
+
+		return output, nil
+	}
+
+	tests := []struct {
+		name        string
+		config      *Config
+		accept      string
+		wantStatus  int
+		wantType    string
+		wantContain string
+	}{
+		{
+			name:       "config default format without Accept",
+			config:     &Config{DefaultFormat: "application/cbor"},
+			wantStatus: http.StatusOK,
+			wantType:   "application/cbor",
+		},
+		{
+			name:       "config default format for wildcard",
+			config:     &Config{DefaultFormat: "application/cbor"},
+			accept:     "*/*",
+			wantStatus: http.StatusOK,
+			wantType:   "application/cbor",
+		},
+		{
+			name:       "fallback to default format for unknown type",
+			config:     &Config{},
+			accept:     "application/xml",
+			wantStatus: http.StatusOK,
+			wantType:   "application/json",
+		},
+		{
+			name:        "strict mode rejects unknown type",
+			config:      &Config{NoFormatFallback: true},
+			accept:      "application/xml",
+			wantStatus:  http.StatusNotAcceptable,
+			wantType:    "application/problem+json",
+			wantContain: "application/json, application/cbor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewMux()
+			adapter := &testChiAdapter{router: router}
+			api := NewAPI(adapter, WithConfig(tt.config))
+			Get(api, "/users/{id}", handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, tt.wantStatus, recorder.Code)
+			assert.Equal(t, tt.wantType, recorder.Header().Get("Content-Type"))
+			if tt.wantContain != "" {
+				assert.Contains(t, recorder.Body.String(), tt.wantContain)
+			}
+		})
+	}
+}
+
+func TestRequestBody_FormatDecoding(t *testing.T) {
+	type CreateUserInput struct {
+		Org  string `schema:"org,location=path"`
+		Body struct {
+			Name string `json:"name" schema:"name"`
+		} `body:"structured"`
+	}
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter)
+
+	Post(api, "/orgs/{org}/users", func(ctx context.Context, input *CreateUserInput) (*GetUserOutput, error) {
+		output := &GetUserOutput{}
+		output.Body.Name = input.Org + "/" + input.Body.Name
+
+		return output, nil
+	})
+
+	cborBody, err := cbor.Marshal(map[string]any{"name": "alice"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+		wantContain string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        []byte(`{"name":"alice"}`),
+			wantStatus:  http.StatusOK,
+			wantContain: `"acme/alice"`,
+		},
+		{
+			name:        "cbor",
+			contentType: "application/cbor",
+			body:        cborBody,
+			wantStatus:  http.StatusOK,
+			wantContain: `"acme/alice"`,
+		},
+		{
+			name:        "cbor suffix",
+			contentType: "application/vnd.user+cbor",
+			body:        cborBody,
+			wantStatus:  http.StatusOK,
+			wantContain: `"acme/alice"`,
+		},
+		{
+			name:        "url-encoded form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        []byte("name=alice"),
+			wantStatus:  http.StatusOK,
+			wantContain: `"acme/alice"`,
+		},
+		{
+			name:        "xml without format",
+			contentType: "application/xml; charset=utf-8",
+			body:        []byte("<user><Name>alice</Name></user>"),
+			wantStatus:  http.StatusOK,
+			wantContain: `"acme/alice"`,
+		},
+		{
+			name:        "unsupported media type",
+			contentType: "text/plain",
+			body:        []byte("alice"),
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantContain: "supported media types: application/json, application/cbor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/orgs/acme/users", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, tt.wantStatus, recorder.Code, recorder.Body.String())
+			assert.Contains(t, recorder.Body.String(), tt.wantContain)
+		})
+	}
+}
+
+func TestFormats_OptIn(t *testing.T) {
+	type CreateUserInput struct {
+		Body struct {
+			Name string `json:"name" xml:"name"`
+		} `body:"structured"`
+	}
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithFormats(map[string]Format{
+		"application/xml":     XMLFormat(),
+		"xml":                 XMLFormat(),
+		"application/yaml":    YAMLFormat(),
+		"yaml":                YAMLFormat(),
+		"application/msgpack": MsgPackFormat(),
+	}))
+
+	Post(api, "/users", func(ctx context.Context, input *CreateUserInput) (*GetUserOutput, error) {
+		if input.Body.Name == "" {
+			return nil, Error400BadRequest("name is required")
+		}
+		if input.Body.Name == "-" {
+			return nil, Error400BadRequest("invalid name", &ErrorDetail{Code: "pattern", Location: "body.name"})
+		}
+
+		output := &GetUserOutput{}
+		output.Body.ID = 1
+		output.Body.Name = input.Body.Name
+
+		return output, nil
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		accept      string
+		wantStatus  int
+		wantType    string
+		wantBody    string
+	}{
+		{
+			name:        "yaml request, yaml response",
+			contentType: "application/yaml",
+			body:        "name: alice\n",
+			accept:      "application/yaml",
+			wantStatus:  http.StatusOK,
+			wantType:    "application/yaml",
+			wantBody:    "id: 1\nname: alice\n",
+		},
+		{
+			name:        "xml suffix request, json response",
+			contentType: "application/vnd.user+xml",
+			body:        "<user><name>bob</name></user>",
+			accept:      "application/json",
+			wantStatus:  http.StatusOK,
+			wantType:    "application/json",
+			wantBody:    `{"id":1,"name":"bob"}` + "\n",
+		},
+		{
+			name:        "xml error",
+			contentType: "application/json",
+			body:        `{}`,
+			accept:      "application/xml",
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "application/problem+xml",
+			wantBody:    `<problem xmlns="urn:ietf:rfc:7807"><title>Bad Request</title><status>400</status><detail>name is required</detail></problem>`,
+		},
+		{
+			name:        "xml error details",
+			contentType: "application/json",
+			body:        `{"name":"-"}`,
+			accept:      "application/xml",
+			wantStatus:  http.StatusBadRequest,
+			wantType:    "application/problem+xml",
+			wantBody:    `<problem xmlns="urn:ietf:rfc:7807"><title>Bad Request</title><status>400</status><detail>invalid name</detail><errors><i><code>pattern</code><location>body.name</location></i></errors></problem>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept", tt.accept)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, tt.wantStatus, recorder.Code, recorder.Body.String())
+			assert.Equal(t, tt.wantType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantBody, recorder.Body.String())
+		})
+	}
+
+	// The spec lists every registered media type
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			RequestBody struct {
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Content map[string]json.RawMessage `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+
+	op := spec.Paths["/users"]["post"]
+	assert.ElementsMatch(t, []string{"application/json", "application/cbor", "application/xml", "application/yaml", "application/msgpack"}, slices.Collect(maps.Keys(op.RequestBody.Content)))
+	assert.ElementsMatch(t, []string{"application/json", "application/cbor", "application/xml", "application/yaml", "application/msgpack"}, slices.Collect(maps.Keys(op.Responses["200"].Content)))
+	assert.ElementsMatch(t, []string{"application/problem+json", "application/problem+cbor", "application/problem+xml", "application/yaml", "application/msgpack"}, slices.Collect(maps.Keys(op.Responses["500"].Content)))
+}
+
+// failingJSON is a response body field that cannot be marshaled.
+type failingJSON struct{}
+
+func (failingJSON) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("boom")
+}
+
+func TestMarshalFailure(t *testing.T) {
+	type FailingOutput struct {
+		Body struct {
+			Value failingJSON `json:"value"`
+		} `body:"structured"`
+	}
+
+	var reported []error
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithMarshalErrorHandler(func(r *http.Request, contentType string, err error) {
+		assert.Equal(t, "application/json", contentType)
+		reported = append(reported, err)
+	}))
+
+	handler := func(ctx context.Context, _ *struct{}) (*FailingOutput, error) {
+		return &FailingOutput{}, nil
+	}
+	Get(api, "/buffered", handler)
+	Get(api, "/unbuffered", handler, func(r *BaseRoute) {
+		r.UnbufferedResponse = true
+	})
+
+	// Buffered responses are replaced by a 500 error
+	req := httptest.NewRequest(http.MethodGet, "/buffered", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "failed to marshal response")
+
+	// Unbuffered responses keep the committed status
+	req = httptest.NewRequest(http.MethodGet, "/unbuffered", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+
+	require.Len(t, reported, 2)
+	assert.ErrorContains(t, reported[0], "boom")
+}
+
+func TestRoutes(t *testing.T) {
+	type CreateUserInput struct {
+		Body struct {
+			Name string `json:"name"`
+		} `body:"structured"`
+	}
+
+	passThrough := func(next http.Handler) http.Handler { return next }
+
+	api := NewAPI(&testChiAdapter{router: chi.NewMux()})
+	api.UseMiddleware(passThrough)
+
+	Get(api, "/users/{id}", func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		return &GetUserOutput{}, nil
+	}, func(r *BaseRoute) {
+		r.Operation = &Operation{OperationID: "get-user"}
+	})
+
+	admin := NewGroup(api, "/admin", "/internal")
+	admin.UseMiddleware(passThrough)
+	admin.UseRoles("admin")
+	Post(admin, "/users", func(ctx context.Context, input *CreateUserInput) (*struct{}, error) {
+		return &struct{}{}, nil
+	}, func(r *BaseRoute) {
+		r.Middlewares = Middlewares{passThrough}
+	})
+
+	routes := api.Routes()
+	require.Len(t, routes, 3)
+
+	assert.Equal(t, RouteInfo{
+		Method:      http.MethodGet,
+		Path:        "/users/{id}",
+		OperationID: "get-user",
+		InputType:   reflect.TypeFor[GetUserInput](),
+		OutputType:  reflect.TypeFor[GetUserOutput](),
+		Middlewares: 1,
+	}, routes[0])
+
+	for i, path := range []string{"/admin/users", "/internal/users"} {
+		route := routes[i+1]
+		assert.Equal(t, http.MethodPost, route.Method)
+		assert.Equal(t, path, route.Path, "routes must have the group prefix")
+		assert.Equal(t, reflect.TypeFor[CreateUserInput](), route.InputType)
+		assert.Equal(t, 3, route.Middlewares, "API, group and route middlewares must be counted")
+		require.NotNil(t, route.Security)
+		assert.Equal(t, []string{"admin"}, route.Security.Roles)
+	}
+
+	// Routes returns copies
+	routes[1].Security.Roles[0] = "guest"
+	routes[1].Path = "/changed"
+	assert.Equal(t, []string{"admin"}, api.Routes()[1].Security.Roles)
+	assert.Equal(t, "/admin/users", api.Routes()[1].Path)
+
+	// Groups list the routes of the whole API
+	assert.Len(t, admin.Routes(), 3)
+}
@@ -0,0 +1,89 @@
+package zorya
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// Authorizer enforces route security requirements. Register one with
+// WithAuthorizer to have Zorya reject unauthorized requests before the
+// handler runs. Routes without security requirements never reach the
+// authorizer.
+//
+// Example:
+//
+//	type tokenAuthorizer struct{}
+//
+//	func (tokenAuthorizer) Authenticate(r *http.Request) (any, error) {
+//		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+//		if !ok {
+//			return nil, nil // anonymous, results in 401
+//		}
+//		return lookupUser(token)
+//	}
+//
+//	func (tokenAuthorizer) Authorize(r *http.Request, principal any, sec *zorya.RouteSecurityContext) error {
+//		user := principal.(*User)
+//		if !zorya.HasRequiredRoles(user.Roles, sec.Roles) {
+//			return zorya.Error403Forbidden("missing required role")
+//		}
+//		return nil
+//	}
+type Authorizer interface {
+	// Authenticate extracts the principal (the authenticated caller) from the
+	// request. It returns a nil principal and nil error for anonymous requests,
+	// which are rejected with 401 Unauthorized. A returned error is written as
+	// is if it implements StatusError, otherwise it results in 401.
+	Authenticate(r *http.Request) (any, error)
+
+	// Authorize decides whether the principal satisfies the resolved route
+	// requirements (roles, permissions, resource and action). A returned
+	// error is written as is if it implements StatusError, otherwise it
+	// results in 403 Forbidden.
+	Authorize(r *http.Request, principal any, sec *RouteSecurityContext) error
+}
+
+const principalContextKey contextKey = "principal"
+
+// GetPrincipal retrieves the principal authenticated by the Authorizer from
+// the request context. Returns nil for public routes or when no Authorizer is
+// configured.
+func GetPrincipal(r *http.Request) any {
+	return PrincipalFromContext(r.Context())
+}
+
+// PrincipalFromContext retrieves the principal authenticated by the Authorizer
+// from a context, such as the one passed to operation handlers.
+func PrincipalFromContext(ctx context.Context) any {
+	return ctx.Value(principalContextKey)
+}
+
+// HasRequiredRoles reports whether granted contains at least one of the
+// required roles, matching the semantics of Roles. An empty required list is
+// always satisfied.
+func HasRequiredRoles(granted, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+
+	for _, role := range required {
+		if slices.Contains(granted, role) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRequiredPermissions reports whether granted contains all of the required
+// permissions, matching the semantics of Permissions.
+func HasRequiredPermissions(granted, required []string) bool {
+	for _, perm := range required {
+		if !slices.Contains(granted, perm) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,135 @@
+package zorya
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPrincipal struct {
+	Name  string
+	Roles []string
+}
+
+type testAuthorizer struct{}
+
+func (testAuthorizer) Authenticate(r *http.Request) (any, error) {
+	switch strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") {
+	case "alice":
+		return &testPrincipal{Name: "alice", Roles: []string{"viewer"}}, nil
+	case "bob":
+		return &testPrincipal{Name: "bob", Roles: []string{"viewer", "admin"}}, nil
+	}
+
+	return nil, nil
+}
+
+func (testAuthorizer) Authorize(r *http.Request, principal any, sec *RouteSecurityContext) error {
+	if !HasRequiredRoles(principal.(*testPrincipal).Roles, sec.Roles) {
+		return Error403Forbidden("missing required role")
+	}
+
+	return nil
+}
+
+type whoAmIOutput struct {
+	Body struct {
+		Name string `json:"name"`
+	} `body:"structured"`
+}
+
+func TestAuthorizer_EnforcesRouteSecurity(t *testing.T) {
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router}, WithAuthorizer(testAuthorizer{}))
+
+	whoAmI := func(ctx context.Context, _ *struct{}) (*whoAmIOutput, error) {
+		out := &whoAmIOutput{}
+		if p, ok := PrincipalFromContext(ctx).(*testPrincipal); ok {
+			out.Body.Name = p.Name
+		}
+
+		return out, nil
+	}
+
+	Get(api, "/public", whoAmI)
+	Get(api, "/admin", whoAmI, Secure(Roles("admin")))
+
+	admin := NewGroup(api, "/group")
+	admin.UseRoles("admin")
+	Get(admin, "/admin", whoAmI)
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{name: "public route is not enforced", path: "/public", status: http.StatusOK},
+		{name: "anonymous request is rejected", path: "/admin", status: http.StatusUnauthorized},
+		{name: "missing role is rejected", path: "/admin", token: "alice", status: http.StatusForbidden},
+		{name: "required role is accepted", path: "/admin", token: "bob", status: http.StatusOK, body: "bob"},
+		{name: "group security is enforced", path: "/group/admin", token: "alice", status: http.StatusForbidden},
+		{name: "group security accepts role", path: "/group/admin", token: "bob", status: http.StatusOK, body: "bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, tt.status, recorder.Code)
+			if tt.status != http.StatusOK {
+				assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+
+				var model ErrorModel
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &model))
+				assert.Equal(t, tt.status, model.Status)
+
+				return
+			}
+
+			var body struct {
+				Name string `json:"name"`
+			}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			assert.Equal(t, tt.body, body.Name)
+		})
+	}
+}
+
+func TestAuthorizer_NotConfiguredKeepsMetadataOnly(t *testing.T) {
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router})
+
+	var captured *RouteSecurityContext
+	api.UseMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			captured = GetRouteSecurityContext(r)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	Get(api, "/admin", func(ctx context.Context, _ *struct{}) (*whoAmIOutput, error) {
+		return &whoAmIOutput{}, nil
+	}, Secure(Roles("admin")))
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	require.NotNil(t, captured)
+	assert.Equal(t, []string{"admin"}, captured.Roles)
+	assert.Equal(t, http.MethodGet, captured.Action)
+}
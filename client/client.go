@@ -0,0 +1,592 @@
+// Package client is the runtime of the Go clients generated by
+// zorya.GenerateClient. It encodes route input structs into HTTP requests and
+// decodes responses into route output structs, using the same schema tags as
+// the server:
+//
+//	c := client.New("https://api.example.com")
+//	out, err := client.Do[GetUserInput, GetUserOutput](ctx, c, http.MethodGet, "/users/{id}", &GetUserInput{ID: 42})
+//
+//	var model *zorya.ErrorModel
+//	if errors.As(err, &model) && model.Status == http.StatusNotFound {
+//		// ...
+//	}
+//
+// Generated clients wrap these calls in a typed method per route.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/talav/schema"
+	"github.com/talav/zorya"
+)
+
+const contentTypeJSON = "application/json"
+
+// Client sends requests to a Zorya API.
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	formats     map[string]zorya.Format
+	contentType string
+	accept      string
+	header      http.Header
+	editors     []RequestEditor
+	metadata    *schema.Metadata
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// RequestEditor modifies a request before it is sent, e.g. to add
+// credentials.
+type RequestEditor func(r *http.Request) error
+
+// New creates a client for the API served at baseURL, which may include a
+// base path. Request bodies are sent as JSON unless WithContentType is used.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		httpClient:  http.DefaultClient,
+		formats:     zorya.DefaultFormats(),
+		contentType: contentTypeJSON,
+		header:      http.Header{},
+		metadata:    zorya.NewMetadata(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header to every request.
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		c.header.Add(name, value)
+	}
+}
+
+// WithRequestEditor adds a function that modifies every request before it is
+// sent. Editors run in the order they are added.
+func WithRequestEditor(editor RequestEditor) Option {
+	return func(c *Client) {
+		c.editors = append(c.editors, editor)
+	}
+}
+
+// WithFormat registers a format used to encode request bodies and decode
+// responses in the given content type. JSON and CBOR are registered by
+// default.
+func WithFormat(contentType string, format zorya.Format) Option {
+	return func(c *Client) {
+		c.formats[contentType] = format
+	}
+}
+
+// WithContentType sets the media type request bodies are encoded in. A format
+// must be registered for it. Defaults to application/json.
+func WithContentType(contentType string) Option {
+	return func(c *Client) {
+		c.contentType = contentType
+	}
+}
+
+// WithAccept sets the Accept header of requests. Defaults to the content type
+// set with WithContentType.
+func WithAccept(accept string) Option {
+	return func(c *Client) {
+		c.accept = accept
+	}
+}
+
+// Do sends a request for the route with the given method and path pattern,
+// built from input, and decodes the response into the route's output type O.
+// Responses with a 4xx or 5xx status are returned as a *zorya.ErrorModel.
+func Do[I, O any](ctx context.Context, c *Client, method, path string, input *I) (*O, error) {
+	resp, err := Stream(ctx, c, method, path, input)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	output := new(O)
+	if err := c.decodeOutput(resp, reflect.ValueOf(output).Elem()); err != nil {
+		return nil, fmt.Errorf("client: failed to decode %s %s response: %w", method, path, err)
+	}
+
+	return output, nil
+}
+
+// Stream sends a request like Do and returns the response without reading its
+// body, for routes streaming their output (body functions, event streams and
+// Content bodies). The caller must close the response body. Responses with a
+// 4xx or 5xx status are returned as a *zorya.ErrorModel.
+func Stream[I any](ctx context.Context, c *Client, method, path string, input *I) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, method, path, input)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer func() { _ = resp.Body.Close() }()
+
+		return nil, c.decodeError(resp)
+	}
+
+	return resp, nil
+}
+
+// NewRequest builds the request for the route with the given method and path
+// pattern from input, a pointer to the route's input struct (or nil): path,
+// query, header and cookie fields are encoded according to their schema tags
+// and the `body:"structured"` field is encoded in the client's content type.
+// Zero query, header and cookie values are omitted, so server defaults apply.
+func (c *Client) NewRequest(ctx context.Context, method, path string, input any) (*http.Request, error) {
+	query := url.Values{}
+	header := c.header.Clone()
+	var cookies []*http.Cookie
+	var body io.Reader
+
+	if vi := reflect.ValueOf(input); vi.Kind() == reflect.Pointer && !vi.IsNil() {
+		vi = vi.Elem()
+
+		structMeta, err := c.metadata.GetStructMetadata(vi.Type())
+		if err != nil {
+			return nil, fmt.Errorf("client: failed to get struct metadata: %w", err)
+		}
+
+		for i := range structMeta.Fields {
+			fieldMeta := &structMeta.Fields[i]
+			field := vi.Field(fieldMeta.Index)
+
+			if fieldMeta.HasTag("body") {
+				if body, err = c.encodeBody(vi.Type().Field(fieldMeta.Index), field); err != nil {
+					return nil, err
+				}
+				if body != nil {
+					header.Set("Content-Type", c.contentType)
+				}
+
+				continue
+			}
+
+			schemaMeta, ok := schema.GetTagMetadata[*schema.SchemaMetadata](fieldMeta, "schema")
+			if !ok {
+				continue
+			}
+
+			values, err := formatParam(field, schemaMeta.Location)
+			if err != nil {
+				return nil, fmt.Errorf("client: %s parameter %s: %w", schemaMeta.Location, schemaMeta.ParamName, err)
+			}
+
+			switch schemaMeta.Location {
+			case schema.LocationPath:
+				if path, err = expandPath(path, schemaMeta.ParamName, values); err != nil {
+					return nil, err
+				}
+			case schema.LocationQuery:
+				for _, value := range values {
+					query.Add(schemaMeta.ParamName, value)
+				}
+			case schema.LocationHeader:
+				for _, value := range values {
+					header.Add(schemaMeta.ParamName, value)
+				}
+			case schema.LocationCookie:
+				for _, value := range values {
+					cookies = append(cookies, &http.Cookie{Name: schemaMeta.ParamName, Value: value})
+				}
+			default:
+			}
+		}
+	}
+
+	if loc := pathParamPattern.FindStringIndex(path); loc != nil {
+		return nil, fmt.Errorf("client: missing path parameter %s", path[loc[0]:loc[1]])
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+
+	req.Header = header
+	if req.Header.Get("Accept") == "" {
+		accept := c.accept
+		if accept == "" {
+			accept = c.contentType
+		}
+		req.Header.Set("Accept", accept)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	for _, edit := range c.editors {
+		if err := edit(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return req, nil
+}
+
+// encodeBody encodes the request body field in the client's content type.
+// Returns nil if the body is a nil pointer, slice or map.
+func (c *Client) encodeBody(structField reflect.StructField, field reflect.Value) (io.Reader, error) {
+	if mode, _, _ := strings.Cut(structField.Tag.Get("body"), ","); mode != "structured" {
+		return nil, fmt.Errorf("client: %s request bodies are not supported", mode)
+	}
+
+	switch field.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		if field.IsNil() {
+			return nil, nil
+		}
+	default:
+	}
+
+	if b, ok := field.Interface().([]byte); ok {
+		return bytes.NewReader(b), nil
+	}
+
+	format, ok := c.format(c.contentType)
+	if !ok || format.Marshal == nil {
+		return nil, fmt.Errorf("client: no format registered for content type %q", c.contentType)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Marshal(&buf, field.Interface()); err != nil {
+		return nil, fmt.Errorf("client: failed to marshal request body: %w", err)
+	}
+
+	return &buf, nil
+}
+
+// decodeOutput fills the header and body fields of the output struct vo from
+// the response. Streaming bodies are left unset.
+func (c *Client) decodeOutput(resp *http.Response, vo reflect.Value) error {
+	structMeta, err := c.metadata.GetStructMetadata(vo.Type())
+	if err != nil {
+		return fmt.Errorf("failed to get struct metadata: %w", err)
+	}
+
+	for i := range structMeta.Fields {
+		fieldMeta := &structMeta.Fields[i]
+
+		schemaMeta, ok := schema.GetTagMetadata[*schema.SchemaMetadata](fieldMeta, "schema")
+		if !ok || schemaMeta.Location != schema.LocationHeader {
+			continue
+		}
+
+		values := resp.Header.Values(schemaMeta.ParamName)
+		if len(values) == 0 {
+			continue
+		}
+		if err := setHeaderField(vo.Field(fieldMeta.Index), values); err != nil {
+			return fmt.Errorf("header %s: %w", schemaMeta.ParamName, err)
+		}
+	}
+
+	bodyFieldMeta := zorya.FindBodyField(structMeta)
+	if bodyFieldMeta == nil {
+		return nil
+	}
+
+	bodyField := vo.Field(bodyFieldMeta.Index)
+	if bodyField.Kind() == reflect.Func || bodyField.Type() == reflect.TypeFor[zorya.Content]() {
+		return nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	if bodyField.Type() == reflect.TypeFor[[]byte]() {
+		bodyField.SetBytes(data)
+
+		return nil
+	}
+
+	return c.unmarshal(resp.Header.Get("Content-Type"), data, bodyField.Addr().Interface())
+}
+
+// decodeError decodes an error response into a *zorya.ErrorModel. Responses
+// that are not problem documents (e.g. from a proxy) get an ErrorModel with
+// the response status.
+func (c *Client) decodeError(resp *http.Response) error {
+	model := &zorya.ErrorModel{}
+
+	if data, err := io.ReadAll(resp.Body); err == nil && len(data) > 0 {
+		if err := c.unmarshal(resp.Header.Get("Content-Type"), data, model); err != nil {
+			model = &zorya.ErrorModel{Detail: strings.TrimSpace(string(data))}
+		}
+	}
+
+	if model.Status == 0 {
+		model.Status = resp.StatusCode
+	}
+	if model.Title == "" {
+		model.Title = http.StatusText(resp.StatusCode)
+	}
+	if model.Detail == "" {
+		model.Detail = model.Title
+	}
+
+	return model
+}
+
+// unmarshal decodes data with the format for the media type of the
+// Content-Type header, which defaults to JSON.
+func (c *Client) unmarshal(header string, data []byte, v any) error {
+	ct := contentTypeJSON
+	if header != "" {
+		var err error
+		if ct, _, err = mime.ParseMediaType(header); err != nil {
+			return fmt.Errorf("invalid Content-Type %q: %w", header, err)
+		}
+	}
+
+	format, ok := c.format(ct)
+	if !ok || format.Unmarshal == nil {
+		return fmt.Errorf("no format registered for content type %q", ct)
+	}
+
+	return format.Unmarshal(data, v)
+}
+
+// format returns the format registered for the content type, falling back to
+// the plus-segment suffix (e.g., application/problem+json -> json).
+func (c *Client) format(ct string) (zorya.Format, bool) {
+	f, ok := c.formats[ct]
+	if !ok {
+		if idx := strings.LastIndex(ct, "+"); idx != -1 {
+			f, ok = c.formats[ct[idx+1:]]
+		}
+	}
+
+	return f, ok
+}
+
+// pathParamPattern matches {name} and {name...} placeholders of a path pattern.
+var pathParamPattern = regexp.MustCompile(`\{[^{}]+\}`)
+
+// expandPath substitutes the value of the named parameter for its placeholder.
+// Wildcard parameters ({name...}) keep their slashes.
+func expandPath(path, name string, values []string) (string, error) {
+	if len(values) == 0 {
+		if strings.Contains(path, "{"+name+"}") || strings.Contains(path, "{"+name+"...}") {
+			return "", fmt.Errorf("client: missing path parameter {%s}", name)
+		}
+
+		return path, nil
+	}
+
+	value := strings.Join(values, ",")
+	path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.ReplaceAll(path, "{"+name+"...}", strings.Join(segments, "/")), nil
+}
+
+// formatParam formats a parameter field into its values. Slices yield one
+// value per element. Zero values are omitted except for path parameters.
+func formatParam(field reflect.Value, location schema.ParameterLocation) ([]string, error) {
+	for field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface {
+		if field.IsNil() {
+			return nil, nil
+		}
+		field = field.Elem()
+	}
+
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]string, 0, field.Len())
+		for i := range field.Len() {
+			value, err := formatValue(field.Index(i), location)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	if field.IsZero() && location != schema.LocationPath {
+		return nil, nil
+	}
+
+	value, err := formatValue(field, location)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{value}, nil
+}
+
+// formatValue formats a single parameter value. Times are written as HTTP
+// dates in headers and in RFC 3339 elsewhere.
+func formatValue(v reflect.Value, location schema.ParameterLocation) (string, error) {
+	if t, ok := v.Interface().(time.Time); ok {
+		if location == schema.LocationHeader {
+			return t.UTC().Format(http.TimeFormat), nil
+		}
+
+		return t.Format(time.RFC3339Nano), nil
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported parameter type %s", v.Type())
+	}
+}
+
+// setHeaderField parses header values into field. Slices receive every value;
+// other types receive the first one.
+func setHeaderField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setHeaderValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+
+		return nil
+	}
+
+	return setHeaderValue(field, values[0])
+}
+
+// setHeaderValue parses a single header value into v.
+func setHeaderValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return setHeaderValue(v.Elem(), value)
+	}
+
+	if v.Type() == reflect.TypeFor[time.Time]() {
+		t, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported header field type %s", v.Type())
+	}
+
+	return nil
+}
+
+// timeLayouts are the layouts time header values are parsed with: HTTP dates,
+// RFC 3339 and the time.Time.String format Zorya writes by default.
+var timeLayouts = []string{http.TimeFormat, time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"}
+
+// parseTime parses a time header value in any of timeLayouts.
+func parseTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,230 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/adapters"
+	"github.com/talav/zorya/client"
+)
+
+type Item struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type UpdateItemInput struct {
+	ID      string    `schema:"id,location=path,required=true"`
+	DryRun  bool      `schema:"dry_run,location=query"`
+	Tags    []string  `schema:"tag,location=query"`
+	Tenant  string    `schema:"X-Tenant,location=header"`
+	Since   time.Time `schema:"If-Unmodified-Since,location=header"`
+	Session string    `schema:"session,location=cookie"`
+	Body    struct {
+		Name string `json:"name"`
+	} `body:"structured"`
+}
+
+type UpdateItemOutput struct {
+	Version int  `schema:"X-Version,location=header"`
+	Body    Item `body:"structured"`
+}
+
+type ExportOutput struct {
+	Body func(w http.ResponseWriter) error
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	api := zorya.NewAPI(adapters.NewStdlib(mux))
+
+	zorya.Put(api, "/items/{id}", func(ctx context.Context, input *UpdateItemInput) (*UpdateItemOutput, error) {
+		if input.ID == "missing" {
+			return nil, zorya.Error404NotFound("item not found")
+		}
+		if input.Session != "s1" {
+			return nil, zorya.Error401Unauthorized("no session")
+		}
+
+		out := &UpdateItemOutput{Version: 7}
+		out.Body = Item{
+			ID:    input.ID,
+			Name:  input.Body.Name + "|" + input.Tenant + "|" + input.Since.UTC().Format(time.RFC3339),
+			Count: len(input.Tags),
+		}
+		if input.DryRun {
+			out.Body.Name = "dry:" + out.Body.Name
+		}
+
+		return out, nil
+	})
+	zorya.Get(api, "/export", func(ctx context.Context, _ *struct{}) (*ExportOutput, error) {
+		return &ExportOutput{Body: func(w http.ResponseWriter) error {
+			w.Header().Set("Content-Type", "text/csv")
+			_, err := io.WriteString(w, "id,name\n1,a\n")
+
+			return err
+		}}, nil
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestDo(t *testing.T) {
+	server := newServer(t)
+	c := client.New(server.URL+"/", client.WithHeader("X-Tenant", "acme"))
+
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	input := &UpdateItemInput{ID: "a1", DryRun: true, Tags: []string{"x", "y"}, Since: since, Session: "s1"}
+	input.Body.Name = "widget"
+
+	out, err := client.Do[UpdateItemInput, UpdateItemOutput](context.Background(), c, http.MethodPut, "/items/{id}", input)
+	require.NoError(t, err)
+	assert.Equal(t, 7, out.Version)
+	assert.Equal(t, Item{ID: "a1", Name: "dry:widget|acme|2024-01-02T03:04:05Z", Count: 2}, out.Body)
+
+	// Error responses are decoded into the error model
+	_, err = client.Do[UpdateItemInput, UpdateItemOutput](context.Background(), c, http.MethodPut, "/items/{id}", &UpdateItemInput{ID: "missing"})
+	var model *zorya.ErrorModel
+	require.ErrorAs(t, err, &model)
+	assert.Equal(t, http.StatusNotFound, model.Status)
+	assert.Equal(t, "item not found", model.Detail)
+
+	// Missing path parameters are reported before sending
+	_, err = client.Do[struct{}, UpdateItemOutput](context.Background(), c, http.MethodPut, "/items/{id}", &struct{}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing path parameter {id}")
+}
+
+func TestNewRequest(t *testing.T) {
+	c := client.New("https://api.example.com/v1",
+		client.WithRequestEditor(func(r *http.Request) error {
+			r.Header.Set("Authorization", "Bearer token")
+
+			return nil
+		}),
+		client.WithContentType("application/cbor"),
+	)
+
+	input := &UpdateItemInput{ID: "a b", Tags: []string{"x"}, Session: "s1"}
+	req, err := c.NewRequest(context.Background(), http.MethodPut, "/items/{id}", input)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://api.example.com/v1/items/a%20b?tag=x", req.URL.String())
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	assert.Equal(t, "application/cbor", req.Header.Get("Content-Type"))
+	assert.Equal(t, "application/cbor", req.Header.Get("Accept"))
+	assert.Empty(t, req.Header.Get("X-Tenant"), "zero values are omitted")
+	cookie, err := req.Cookie("session")
+	require.NoError(t, err)
+	assert.Equal(t, "s1", cookie.Value)
+}
+
+func TestStream(t *testing.T) {
+	server := newServer(t)
+	c := client.New(server.URL)
+
+	resp, err := client.Stream(context.Background(), c, http.MethodGet, "/export", &struct{}{})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,a\n", string(data))
+
+	// Responses that are not problem documents still become an error model
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	_, err = client.Stream(context.Background(), client.New(proxy.URL), http.MethodGet, "/export", &struct{}{})
+	var model *zorya.ErrorModel
+	require.ErrorAs(t, err, &model)
+	assert.Equal(t, http.StatusBadGateway, model.Status)
+	assert.Equal(t, "upstream unavailable", strings.TrimSpace(model.Detail))
+}
+
+type PathParams struct {
+	ID      string `schema:"id,location=path"`
+	Version int    `schema:"version,location=path"`
+}
+
+type QueryParams struct {
+	Search string   `schema:"q,location=query"`
+	Limit  int      `schema:"limit,location=query"`
+	Tags   []string `schema:"tag,location=query"`
+}
+
+type HeaderParams struct {
+	Tenant string    `schema:"X-Tenant,location=header"`
+	Retry  int       `schema:"X-Retry,location=header"`
+	Since  time.Time `schema:"If-Modified-Since,location=header"`
+}
+
+type CookieParams struct {
+	Session string   `schema:"session,location=cookie"`
+	Theme   string   `schema:"theme,location=cookie"`
+	Visits  int      `schema:"visits,location=cookie"`
+	Flags   []string `schema:"flag,location=cookie"`
+}
+
+type EchoOutput[I any] struct {
+	Body *I `body:"structured"`
+}
+
+// roundTrip sends input to a zorya server echoing the decoded input back.
+func roundTrip[I any](t *testing.T, path string, input *I) *I {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	api := zorya.NewAPI(adapters.NewStdlib(mux))
+	zorya.Get(api, path, func(ctx context.Context, input *I) (*EchoOutput[I], error) {
+		return &EchoOutput[I]{Body: input}, nil
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	out, err := client.Do[I, EchoOutput[I]](context.Background(), client.New(server.URL), http.MethodGet, path, input)
+	require.NoError(t, err)
+
+	return out.Body
+}
+
+func TestParameterRoundTrip(t *testing.T) {
+	t.Run("path", func(t *testing.T) {
+		input := &PathParams{ID: "a b/c", Version: 3}
+		assert.Equal(t, input, roundTrip(t, "/items/{id}/versions/{version}", input))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		input := &QueryParams{Search: "a&b=c", Limit: 10, Tags: []string{"x", "y z"}}
+		assert.Equal(t, input, roundTrip(t, "/items", input))
+	})
+
+	t.Run("header", func(t *testing.T) {
+		input := &HeaderParams{Tenant: "acme", Retry: 2, Since: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+		assert.Equal(t, input, roundTrip(t, "/items", input))
+	})
+
+	t.Run("cookie", func(t *testing.T) {
+		input := &CookieParams{Session: "s1", Theme: "dark mode", Visits: 4, Flags: []string{"a", "b"}}
+		assert.Equal(t, input, roundTrip(t, "/items", input))
+	})
+}
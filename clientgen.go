@@ -0,0 +1,492 @@
+package zorya
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// clientRuntimePath is the import path of the runtime used by generated clients.
+const clientRuntimePath = "github.com/talav/zorya/client"
+
+// commonInitialisms are written in upper case in generated method names.
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true, "JSON": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// ClientConfig configures the Go client package generated by GenerateClient.
+type ClientConfig struct {
+	// Package is the name of the generated package. Defaults to "client".
+	Package string
+
+	// TypeName is the name of the generated client type. Defaults to "Client".
+	TypeName string
+}
+
+// GenerateClient generates the source of a typed Go client package for the
+// routes registered on the API. The package declares a client type with a
+// method per route, named after the operation ID or, if there is none, derived
+// from the method and path (GET /users/{id} becomes GetUsersByID). Each method
+// takes the route's input type and returns its output type:
+//
+//	func (c *Client) GetUser(ctx context.Context, input *users.GetUserInput) (*users.GetUserOutput, error)
+//
+// Input and output types are imported from the packages declaring them, so
+// they must be exported. Types declared in package main cannot be imported and
+// are copied into the generated package, without their methods. Routes whose
+// output streams (body functions, EventStream and Content) return the
+// *http.Response instead. Requests are sent with the client package, which
+// encodes parameters according to their schema tags and returns error
+// responses as *ErrorModel.
+//
+// Run it from a small program invoked by go generate:
+//
+//	//go:generate go run ./cmd/genclient
+//
+//	func main() {
+//		api := zorya.NewAPI(adapters.NewStdlib(http.NewServeMux()))
+//		users.RegisterRoutes(api)
+//
+//		src, err := zorya.GenerateClient(api, zorya.ClientConfig{Package: "userclient"})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		if err := os.WriteFile("userclient/client.go", src, 0o644); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func GenerateClient(api API, config ClientConfig) ([]byte, error) {
+	if config.Package == "" {
+		config.Package = "client"
+	}
+	if config.TypeName == "" {
+		config.TypeName = "Client"
+	}
+	if !token.IsIdentifier(config.Package) || !token.IsIdentifier(config.TypeName) {
+		return nil, fmt.Errorf("invalid client package or type name %q, %q", config.Package, config.TypeName)
+	}
+
+	g := newClientGenerator(config)
+	for _, route := range api.registeredRoutes() {
+		if err := g.addMethod(route); err != nil {
+			return nil, fmt.Errorf("route %s %s: %w", route.Method, route.Path, err)
+		}
+	}
+
+	title := "the API"
+	if spec := api.OpenAPI(); spec != nil && spec.Info != nil && spec.Info.Title != "" {
+		title = "the " + spec.Info.Title + " API"
+	}
+
+	src, err := format.Source(g.render(title))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated client: %w", err)
+	}
+
+	return src, nil
+}
+
+// clientMethod is a method of the generated client.
+type clientMethod struct {
+	name   string
+	doc    string
+	method string
+	path   string
+	input  string
+	output string
+	stream bool
+}
+
+// clientGenerator collects the methods, imports and copied type declarations
+// of a generated client.
+type clientGenerator struct {
+	config   ClientConfig
+	imports  map[string]string // Package name by import path
+	names    map[string]bool   // Package names in use
+	types    map[reflect.Type]string
+	typeList []reflect.Type // Types copied from package main, in declaration order
+	methods  []clientMethod
+	routes   map[string]*BaseRoute // Route of each method name
+}
+
+func newClientGenerator(config ClientConfig) *clientGenerator {
+	g := &clientGenerator{
+		config:  config,
+		imports: map[string]string{},
+		names:   map[string]bool{},
+		types:   map[reflect.Type]string{},
+		routes:  map[string]*BaseRoute{},
+	}
+	g.importName("context", "context")
+	g.importName("net/http", "http")
+	g.importName(clientRuntimePath, "client")
+
+	return g
+}
+
+// addMethod adds the client method of the route.
+func (g *clientGenerator) addMethod(route *BaseRoute) error {
+	name := clientMethodName(route)
+	if other, ok := g.routes[name]; ok {
+		return fmt.Errorf("client method %s is already generated for %s %s, set an OperationID", name, other.Method, other.Path)
+	}
+	g.routes[name] = route
+
+	input, err := g.typeExpr(route.inputType)
+	if err != nil {
+		return fmt.Errorf("input type: %w", err)
+	}
+
+	m := clientMethod{
+		name:   name,
+		doc:    clientMethodDoc(name, route),
+		method: route.Method,
+		path:   route.Path,
+		input:  input,
+		stream: hasStreamingBody(route.outputType),
+	}
+	if !m.stream {
+		if m.output, err = g.typeExpr(route.outputType); err != nil {
+			return fmt.Errorf("output type: %w", err)
+		}
+	}
+	g.methods = append(g.methods, m)
+
+	return nil
+}
+
+// render writes the source of the client package.
+func (g *clientGenerator) render(title string) []byte {
+	var b strings.Builder
+
+	b.WriteString("// Code generated by zorya.GenerateClient. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "// Package %s is a client for %s.\n", g.config.Package, title)
+	fmt.Fprintf(&b, "package %s\n\n", g.config.Package)
+
+	b.WriteString("import (\n")
+	for _, path := range g.usedImports() {
+		name := g.imports[path]
+		if path[strings.LastIndex(path, "/")+1:] == name {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&b, "\t%s %q\n", name, path)
+		}
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, "// %s calls the operations of %s.\n", g.config.TypeName, title)
+	fmt.Fprintf(&b, "type %s struct {\n\t*client.Client\n}\n\n", g.config.TypeName)
+	fmt.Fprintf(&b, "// New creates a client for %s served at baseURL.\n", title)
+	fmt.Fprintf(&b, "func New(baseURL string, opts ...client.Option) *%s {\n", g.config.TypeName)
+	fmt.Fprintf(&b, "\treturn &%s{Client: client.New(baseURL, opts...)}\n}\n", g.config.TypeName)
+
+	for _, t := range g.typeList {
+		fmt.Fprintf(&b, "\n// %s is a copy of %s.\n", t.Name(), t)
+		fmt.Fprintf(&b, "type %s %s\n", t.Name(), g.types[t])
+	}
+
+	for _, m := range g.methods {
+		b.WriteString("\n" + m.doc)
+		if m.stream {
+			fmt.Fprintf(&b, "func (c *%s) %s(ctx context.Context, input *%s) (*http.Response, error) {\n", g.config.TypeName, m.name, m.input)
+			fmt.Fprintf(&b, "\treturn client.Stream(ctx, c.Client, %q, %q, input)\n}\n", m.method, m.path)
+
+			continue
+		}
+		fmt.Fprintf(&b, "func (c *%s) %s(ctx context.Context, input *%s) (*%s, error) {\n", g.config.TypeName, m.name, m.input, m.output)
+		fmt.Fprintf(&b, "\treturn client.Do[%s, %s](ctx, c.Client, %q, %q, input)\n}\n", m.input, m.output, m.method, m.path)
+	}
+
+	return []byte(b.String())
+}
+
+// usedImports returns the import paths the generated source refers to.
+func (g *clientGenerator) usedImports() []string {
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		switch {
+		case path == "context" && len(g.methods) == 0:
+		case path == "net/http" && !slices.ContainsFunc(g.methods, func(m clientMethod) bool { return m.stream }):
+		default:
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	return paths
+}
+
+// importName returns the name the package is imported as, importing it under
+// its name, or a numbered name if that name is taken.
+func (g *clientGenerator) importName(path, pkgName string) string {
+	if name, ok := g.imports[path]; ok {
+		return name
+	}
+
+	name := pkgName
+	for i := 2; g.names[name] || name == g.config.TypeName; i++ {
+		name = pkgName + strconv.Itoa(i)
+	}
+	g.imports[path] = name
+	g.names[name] = true
+
+	return name
+}
+
+// typeExpr returns the Go expression of the type in the generated package.
+func (g *clientGenerator) typeExpr(t reflect.Type) (string, error) {
+	if t.Name() == "" {
+		return g.typeLiteral(t)
+	}
+	if strings.Contains(t.Name(), "[") {
+		return "", fmt.Errorf("generic type %s is not supported", t)
+	}
+
+	switch t.PkgPath() {
+	case "":
+		// Predeclared type
+		return t.Name(), nil
+	case "main":
+		if err := g.copyType(t); err != nil {
+			return "", err
+		}
+
+		return t.Name(), nil
+	default:
+	}
+
+	if !token.IsExported(t.Name()) {
+		return "", fmt.Errorf("unexported type %s cannot be referenced", t)
+	}
+	pkgName, _, _ := strings.Cut(t.String(), ".")
+
+	return g.importName(t.PkgPath(), pkgName) + "." + t.Name(), nil
+}
+
+// copyType declares a copy of a package main type in the generated package.
+func (g *clientGenerator) copyType(t reflect.Type) error {
+	if _, ok := g.types[t]; ok {
+		return nil
+	}
+	if t.Name() == g.config.TypeName || t.Name() == "New" || g.names[t.Name()] {
+		return fmt.Errorf("type %s conflicts with a name of the generated package", t)
+	}
+
+	// Register the type before its definition, which may refer to it
+	g.types[t] = ""
+	g.typeList = append(g.typeList, t)
+
+	literal, err := g.typeLiteral(t)
+	if err != nil {
+		return err
+	}
+	g.types[t] = literal
+
+	return nil
+}
+
+// typeLiteral returns the Go expression of the underlying type of t.
+func (g *clientGenerator) typeLiteral(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := g.typeExpr(t.Elem())
+
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeExpr(t.Elem())
+
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeExpr(t.Elem())
+
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeExpr(t.Elem())
+
+		return "map[" + key + "]" + elem, err
+	case reflect.Struct:
+		return g.structLiteral(t)
+	case reflect.Func:
+		return g.funcLiteral(t)
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return "", fmt.Errorf("interface type %s is not supported", t)
+		}
+
+		return "any", nil
+	case reflect.Chan, reflect.UnsafePointer, reflect.Invalid:
+		return "", fmt.Errorf("type %s is not supported", t)
+	default:
+		// Basic kinds are named like their predeclared type
+		return t.Kind().String(), nil
+	}
+}
+
+// structLiteral returns the struct type expression of t, with field tags.
+func (g *clientGenerator) structLiteral(t reflect.Type) (string, error) {
+	if t.NumField() == 0 {
+		return "struct{}", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && field.PkgPath != "main" {
+			return "", fmt.Errorf("unexported field %s of %s cannot be copied", field.Name, t)
+		}
+
+		expr, err := g.typeExpr(field.Type)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if !field.Anonymous {
+			b.WriteString(field.Name + " ")
+		}
+		b.WriteString(expr)
+		if field.Tag != "" {
+			if strings.Contains(string(field.Tag), "`") {
+				b.WriteString(" " + strconv.Quote(string(field.Tag)))
+			} else {
+				b.WriteString(" `" + string(field.Tag) + "`")
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+
+	return b.String(), nil
+}
+
+// funcLiteral returns the function type expression of t.
+func (g *clientGenerator) funcLiteral(t reflect.Type) (string, error) {
+	params := make([]string, t.NumIn())
+	for i := range t.NumIn() {
+		in := t.In(i)
+		prefix := ""
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			in, prefix = in.Elem(), "..."
+		}
+		expr, err := g.typeExpr(in)
+		if err != nil {
+			return "", err
+		}
+		params[i] = prefix + expr
+	}
+
+	results := make([]string, t.NumOut())
+	for i := range t.NumOut() {
+		expr, err := g.typeExpr(t.Out(i))
+		if err != nil {
+			return "", err
+		}
+		results[i] = expr
+	}
+
+	literal := "func(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return literal, nil
+	case 1:
+		return literal + " " + results[0], nil
+	default:
+		return literal + " (" + strings.Join(results, ", ") + ")", nil
+	}
+}
+
+// clientMethodName returns the client method name of the route: its
+// operation ID, or a name derived from its method and path.
+func clientMethodName(route *BaseRoute) string {
+	if route.Operation != nil && route.Operation.OperationID != "" {
+		return goIdentifier(route.Operation.OperationID)
+	}
+
+	return derivedOperationName(route.Method, route.Path)
+}
+
+// derivedOperationName returns an identifier for an operation without ID: its
+// method followed by its path segments, with path parameters prefixed by "By"
+// (GET /users/{id} becomes GetUsersByID).
+func derivedOperationName(method, path string) string {
+	words := []string{strings.ToLower(method)}
+	for segment := range strings.SplitSeq(path, "/") {
+		if param, ok := strings.CutPrefix(segment, "{"); ok {
+			param = strings.TrimSuffix(strings.TrimSuffix(param, "}"), "...")
+			words = append(words, "by", param)
+
+			continue
+		}
+		words = append(words, segment)
+	}
+
+	return goIdentifier(strings.Join(words, " "))
+}
+
+// goIdentifier converts s to an exported Go identifier, capitalizing each
+// word and dropping other characters.
+func goIdentifier(s string) string {
+	var b strings.Builder
+	for word := range strings.FieldsFuncSeq(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b.WriteString(upper)
+
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+
+	name := b.String()
+	if first, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(first) {
+		name = "Op" + name
+	}
+
+	return name
+}
+
+// clientMethodDoc returns the doc comment of a client method, with the
+// operation summary and description.
+func clientMethodDoc(name string, route *BaseRoute) string {
+	paragraphs := []string{fmt.Sprintf("%s calls %s %s.", name, route.Method, route.Path)}
+	if op := route.Operation; op != nil {
+		if op.Summary != "" {
+			paragraphs = append(paragraphs, op.Summary)
+		}
+		if op.Description != "" {
+			paragraphs = append(paragraphs, op.Description)
+		}
+		if op.Deprecated {
+			paragraphs = append(paragraphs, "Deprecated: the operation is deprecated.")
+		}
+	}
+
+	var b strings.Builder
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			b.WriteString("//\n")
+		}
+		for line := range strings.Lines(paragraph) {
+			if line = strings.TrimRight(line, " \t\r\n"); line == "" {
+				b.WriteString("//\n")
+			} else {
+				b.WriteString("// " + line + "\n")
+			}
+		}
+	}
+
+	return b.String()
+}
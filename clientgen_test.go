@@ -0,0 +1,84 @@
+package zorya
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateClient(t *testing.T) {
+	type listInput struct {
+		Limit int `schema:"limit,location=query"`
+	}
+
+	newAPI := func() API {
+		return NewAPI(&testChiAdapter{router: chi.NewMux()}, WithOpenAPI(&OpenAPI{Info: &Info{Title: "Users"}}))
+	}
+
+	api := newAPI()
+	Get(api, "/users/{id}", func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		return &GetUserOutput{}, nil
+	})
+	Post(api, "/users", func(ctx context.Context, input *struct {
+		Body struct {
+			Name string `json:"name"`
+		} `body:"structured"`
+	}) (*struct{}, error) {
+		return &struct{}{}, nil
+	}, func(r *BaseRoute) {
+		r.Operation = &Operation{OperationID: "create-user", Summary: "Create a user."}
+	})
+	Get(api, "/files/{name}", func(ctx context.Context, _ *struct{}) (*struct{ Body Content }, error) {
+		return &struct{ Body Content }{}, nil
+	})
+
+	src, err := GenerateClient(api, ClientConfig{Package: "userclient"})
+	require.NoError(t, err)
+
+	file, err := parser.ParseFile(token.NewFileSet(), "client.go", src, parser.ParseComments)
+	require.NoError(t, err, string(src))
+	assert.Equal(t, "userclient", file.Name.Name)
+
+	imports := make([]string, 0, len(file.Imports))
+	for _, spec := range file.Imports {
+		imports = append(imports, strings.Trim(spec.Path.Value, `"`))
+	}
+	assert.ElementsMatch(t, []string{"context", "net/http", "github.com/talav/zorya", "github.com/talav/zorya/client"}, imports)
+
+	code := string(src)
+	assert.Contains(t, code, "// Code generated by zorya.GenerateClient. DO NOT EDIT.")
+	assert.Contains(t, code, "// Client calls the operations of the Users API.")
+	assert.Contains(t, code, "func (c *Client) GetUsersByID(ctx context.Context, input *zorya.GetUserInput) (*zorya.GetUserOutput, error) {")
+	assert.Contains(t, code, `client.Do[zorya.GetUserInput, zorya.GetUserOutput](ctx, c.Client, "GET", "/users/{id}", input)`)
+	assert.Contains(t, code, "// CreateUser calls POST /users.\n//\n// Create a user.\n")
+	assert.Contains(t, code, "func (c *Client) CreateUser(ctx context.Context, input *struct {")
+	assert.Contains(t, code, "`body:\"structured\"`")
+	assert.Contains(t, code, "func (c *Client) GetFilesByName(ctx context.Context, input *struct{}) (*http.Response, error) {")
+	assert.Contains(t, code, `client.Stream(ctx, c.Client, "GET", "/files/{name}", input)`)
+
+	// Unexported types cannot be referenced by the generated package
+	api = newAPI()
+	Get(api, "/users", func(ctx context.Context, input *listInput) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	_, err = GenerateClient(api, ClientConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexported type")
+
+	// Method names must be unique
+	api = newAPI()
+	for _, path := range []string{"/users-list", "/users/list"} {
+		Get(api, path, func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+			return &struct{}{}, nil
+		})
+	}
+	_, err = GenerateClient(api, ClientConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set an OperationID")
+}
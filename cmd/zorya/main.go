@@ -0,0 +1,14 @@
+// Command zorya compares OpenAPI specs and fails on breaking changes without
+// an info.version bump:
+//
+//	zorya diff base.json revision.json
+//
+// To export the spec of an API, call zoryacli.Main from a main package that
+// builds it.
+package main
+
+import "github.com/talav/zorya/zoryacli"
+
+func main() {
+	zoryacli.Main(nil)
+}
@@ -0,0 +1,374 @@
+package zorya
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/talav/negotiation"
+)
+
+// Content codings supported for response compression.
+const (
+	EncodingZstd    = "zstd"
+	EncodingBrotli  = "br"
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// DefaultCompressionMinSize is the default minimum response body size for
+// compression (1KB).
+const DefaultCompressionMinSize = 1024
+
+// Compression configures response compression. See WithCompression.
+type Compression struct {
+	// Encodings lists the content codings offered in Accept-Encoding
+	// negotiation, in order of preference.
+	// If empty, uses zstd, br, gzip and deflate.
+	Encodings []string
+
+	// MinSize is the minimum body size in bytes for a response to be
+	// compressed. Streaming bodies that are flushed before reaching it are
+	// compressed anyway.
+	// If == 0, uses DefaultCompressionMinSize (1KB).
+	// If < 0, all responses are compressed.
+	MinSize int
+}
+
+// defaultEncodings are the content codings offered when
+// Compression.Encodings is empty, in order of preference.
+var defaultEncodings = []string{EncodingZstd, EncodingBrotli, EncodingGzip, EncodingDeflate}
+
+// incompressibleTypes are media types whose content is already compressed.
+// Other image, audio and video types are also sent as they are.
+var incompressibleTypes = []string{
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/x-bzip2",
+	"application/x-xz",
+	"font/woff",
+	"font/woff2",
+	contentTypeEventStream,
+}
+
+// compressor is an encoder for a content coding that can be reused.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressorPools hold reusable encoders by content coding.
+var compressorPools = map[string]*sync.Pool{
+	EncodingZstd: {New: func() any {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+
+		return encoder
+	}},
+	EncodingBrotli: {New: func() any {
+		return brotli.NewWriterLevel(nil, brotli.DefaultCompression)
+	}},
+	EncodingGzip: {New: func() any {
+		return gzip.NewWriter(nil)
+	}},
+	EncodingDeflate: {New: func() any {
+		// The deflate content coding is the zlib format (RFC 9110 8.4.1.2)
+		return zlib.NewWriter(nil)
+	}},
+}
+
+// Ensure the pooled encoders satisfy compressor.
+var (
+	_ compressor = (*zstd.Encoder)(nil)
+	_ compressor = (*brotli.Writer)(nil)
+	_ compressor = (*gzip.Writer)(nil)
+	_ compressor = (*zlib.Writer)(nil)
+)
+
+// newCompressionMiddleware creates middleware that compresses the responses of
+// the route with the content coding negotiated from the Accept-Encoding header.
+// Returns nil if compression is not enabled or the route opts out of it.
+func newCompressionMiddleware(compression *Compression, route *BaseRoute) Middleware {
+	if compression == nil || route.DisableCompression || route.eventStream {
+		return nil
+	}
+
+	encodings := compression.Encodings
+	if len(encodings) == 0 {
+		encodings = defaultEncodings
+	}
+	encodings = slices.DeleteFunc(slices.Clone(encodings), func(encoding string) bool {
+		return compressorPools[encoding] == nil
+	})
+
+	minSize := compression.MinSize
+	if minSize == 0 {
+		minSize = DefaultCompressionMinSize
+	}
+
+	negotiator := negotiation.NewEncodingNegotiator()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(negotiator, r.Header.Get("Accept-Encoding"), encodings)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize, status: http.StatusOK}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+			cw.completed = true
+		})
+	}
+}
+
+// negotiateEncoding returns the content coding to compress the response with,
+// or an empty string if it must be sent as it is.
+func negotiateEncoding(negotiator *negotiation.Negotiator, acceptEncoding string, encodings []string) string {
+	if acceptEncoding == "" || len(encodings) == 0 {
+		return ""
+	}
+
+	header, err := negotiator.Negotiate(acceptEncoding, encodings, false)
+	if err != nil || header == nil || !slices.Contains(encodings, header.Type) {
+		return ""
+	}
+
+	return header.Type
+}
+
+// compressWriter compresses the response body. The body is buffered until it
+// reaches the minimum size, the handler flushes or the response is complete,
+// so that small and incompressible responses are sent as they are.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	status   int
+	buf      []byte
+	decided  bool
+	encoder  compressor
+
+	// completed is set once the handler has returned without panicking
+	completed bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided {
+		return
+	}
+	w.status = status
+
+	// Responses without a body are sent right away
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		_ = w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minSize {
+			return len(data), nil
+		}
+
+		return len(data), w.decide(true)
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends the buffered body, compressed if the response is compressible,
+// and flushes the underlying writer.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the response headers, compressing the body if compress is set
+// and the response is compressible, and writes the buffered body.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+
+	if compress && len(w.buf) > 0 && header.Get("Content-Type") == "" {
+		// Set it now, as it cannot be sniffed from the compressed body
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if compress && w.compressible(header) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// The compressed representation is not byte-for-byte identical
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		w.encoder = compressorPools[w.encoding].Get().(compressor)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+
+	return err
+}
+
+// compressible reports whether the response can be compressed: it has a body
+// that is not already encoded or served in ranges, and its media type is not
+// compressed itself.
+func (w *compressWriter) compressible(header http.Header) bool {
+	if w.status == http.StatusPartialContent || header.Get("Content-Encoding") != "" || header.Get("Accept-Ranges") == "bytes" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	if strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/") {
+		return false
+	}
+
+	return !slices.Contains(incompressibleTypes, mediaType)
+}
+
+// close sends a response that is still buffered as it is, as it is smaller
+// than the minimum size, and completes the compressed body. If the handler
+// panicked, the buffered body is dropped so that the error response can be
+// written, and a compressed body is left incomplete.
+func (w *compressWriter) close() {
+	if !w.completed {
+		w.buf = nil
+		w.releaseEncoder()
+
+		return
+	}
+
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+	}
+	w.releaseEncoder()
+}
+
+// releaseEncoder returns the encoder to its pool.
+func (w *compressWriter) releaseEncoder() {
+	if w.encoder == nil {
+		return
+	}
+
+	compressorPools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+}
+
+func (a *api) compressionConfig() *Compression {
+	return a.compression
+}
+
+// requestEncodings are the content codings accepted for request bodies.
+var requestEncodings = []string{EncodingGzip, EncodingDeflate, EncodingZstd}
+
+// maxRequestZstdWindow limits the window size of zstd request bodies, which
+// the decoder allocates up front. 8MB is the minimum decoders must support
+// (RFC 8878 3.1.1.1.2).
+const maxRequestZstdWindow = 8 << 20
+
+// decompressRequestBody replaces a request body sent with a Content-Encoding
+// by its decompressed content, decoding the codings in the reverse order they
+// were applied. Returns a 415 error listing the accepted codings for
+// unsupported ones, and a 400 error if the body is not validly encoded.
+func decompressRequestBody(r *http.Request) error {
+	header := strings.Join(r.Header.Values("Content-Encoding"), ",")
+	if header == "" || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	codings := strings.Split(header, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+
+		var err error
+		switch coding {
+		case "", "identity":
+			continue
+		case EncodingGzip, "x-gzip":
+			var reader *gzip.Reader
+			if reader, err = gzip.NewReader(r.Body); err == nil {
+				r.Body = reader
+			}
+		case EncodingDeflate:
+			var reader io.ReadCloser
+			if reader, err = zlib.NewReader(r.Body); err == nil {
+				r.Body = reader
+			}
+		case EncodingZstd:
+			var decoder *zstd.Decoder
+			if decoder, err = zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxRequestZstdWindow)); err == nil {
+				r.Body = decoder.IOReadCloser()
+			}
+		default:
+			return ErrorWithHeaders(
+				Error415UnsupportedMediaType(fmt.Sprintf("unsupported content encoding %q, supported encodings: %s", coding, strings.Join(requestEncodings, ", "))),
+				http.Header{"Accept-Encoding": {strings.Join(requestEncodings, ", ")}},
+			)
+		}
+		if err != nil {
+			return Error400BadRequest(fmt.Sprintf("invalid %s request body", coding), err)
+		}
+	}
+
+	// The body is decompressed, its length is no longer known
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+
+	return nil
+}
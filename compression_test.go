@@ -0,0 +1,292 @@
+package zorya
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression_HandlerPanics(t *testing.T) {
+	type StreamOutput struct {
+		Body func(http.ResponseWriter) error
+	}
+
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router}, WithCompression(&Compression{MinSize: 100}), WithPanicHandler(func(r *http.Request, recovered any, stack []byte) {}))
+
+	large := bytes.Repeat([]byte("compressible "), 100)
+	Get(api, "/buffered", func(ctx context.Context, _ *struct{}) (*StreamOutput, error) {
+		return &StreamOutput{Body: func(w http.ResponseWriter) error {
+			_, _ = w.Write([]byte("partial"))
+			panic("boom")
+		}}, nil
+	})
+	Get(api, "/flushed", func(ctx context.Context, _ *struct{}) (*StreamOutput, error) {
+		return &StreamOutput{Body: func(w http.ResponseWriter) error {
+			_, _ = w.Write(large)
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				return err
+			}
+			panic("boom")
+		}}, nil
+	})
+	Get(api, "/ok", func(ctx context.Context, _ *struct{}) (*StreamOutput, error) {
+		return &StreamOutput{Body: func(w http.ResponseWriter) error {
+			_, err := w.Write(large)
+
+			return err
+		}}, nil
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// The buffered body is dropped and the error is sent uncompressed
+	recorder := serve("/buffered")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.NotContains(t, recorder.Body.String(), "partial")
+	assert.Contains(t, recorder.Body.String(), "Internal Server Error")
+
+	// A compressed body that has been sent is left incomplete
+	recorder = serve("/flushed")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	reader, err := gzip.NewReader(recorder.Body)
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// The encoder is returned to the pool
+	var cw *compressWriter
+	handler := newCompressionMiddleware(&Compression{MinSize: -1}, &BaseRoute{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw = w.(*compressWriter)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write(large)
+		panic("boom")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	assert.Panics(t, func() { handler.ServeHTTP(httptest.NewRecorder(), req) })
+	require.NotNil(t, cw)
+	assert.Nil(t, cw.encoder)
+
+	// The encoders returned to the pool keep working
+	for range 3 {
+		recorder = serve("/ok")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(recorder.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, large, body)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	type Item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	type ListOutput struct {
+		ETag string `schema:"ETag,location=header"`
+		Body []Item `body:"structured"`
+	}
+	type StreamOutput struct {
+		Body func(http.ResponseWriter) error
+	}
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithCompression(&Compression{}))
+
+	list := func(n int) func(ctx context.Context, _ *struct{}) (*ListOutput, error) {
+		return func(ctx context.Context, _ *struct{}) (*ListOutput, error) {
+			out := &ListOutput{ETag: `"v1"`}
+			for i := range n {
+				out.Body = append(out.Body, Item{ID: i, Name: "item"})
+			}
+
+			return out, nil
+		}
+	}
+	Get(api, "/items", list(100))
+	Get(api, "/small", list(1))
+	Get(api, "/raw", list(100), func(r *BaseRoute) {
+		r.DisableCompression = true
+	})
+	Get(api, "/stream", func(ctx context.Context, _ *struct{}) (*StreamOutput, error) {
+		return &StreamOutput{Body: func(w http.ResponseWriter) error {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("first\n"))
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				return err
+			}
+			_, err := w.Write([]byte("second\n"))
+
+			return err
+		}}, nil
+	})
+
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// Large responses are compressed and their ETag is weakened
+	recorder := serve("/items", "gzip, deflate")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, `W/"v1"`, recorder.Header().Get("ETag"))
+	assert.Contains(t, recorder.Header().Values("Vary"), "Accept-Encoding")
+
+	reader, err := gzip.NewReader(recorder.Body)
+	require.NoError(t, err)
+	var items []Item
+	require.NoError(t, json.NewDecoder(reader).Decode(&items))
+	assert.Len(t, items, 100)
+
+	recorder = serve("/items", "br;q=1.0, gzip;q=0.5")
+	assert.Equal(t, "br", recorder.Header().Get("Content-Encoding"))
+	require.NoError(t, json.NewDecoder(brotli.NewReader(recorder.Body)).Decode(&items))
+
+	recorder = serve("/items", "zstd")
+	assert.Equal(t, "zstd", recorder.Header().Get("Content-Encoding"))
+	decoder, err := zstd.NewReader(recorder.Body)
+	require.NoError(t, err)
+	defer decoder.Close()
+	require.NoError(t, json.NewDecoder(decoder).Decode(&items))
+
+	// Without Accept-Encoding, below the minimum size or opted out, the body is sent as it is
+	for _, tc := range []struct{ path, acceptEncoding string }{
+		{"/items", ""},
+		{"/small", "gzip"},
+		{"/raw", "gzip"},
+	} {
+		recorder = serve(tc.path, tc.acceptEncoding)
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"), tc.path)
+		assert.Equal(t, `"v1"`, recorder.Header().Get("ETag"), tc.path)
+		assert.True(t, json.Valid(recorder.Body.Bytes()), tc.path)
+	}
+
+	// Flushed streaming bodies are compressed as they are written
+	recorder = serve("/stream", "gzip")
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	assert.True(t, recorder.Flushed)
+	reader, err = gzip.NewReader(recorder.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(body))
+}
+
+func TestCompressedRequestBody(t *testing.T) {
+	type Item struct {
+		Name string `json:"name"`
+	}
+	type CreateInput struct {
+		Body Item `body:"structured"`
+	}
+	type CreateOutput struct {
+		Body Item `body:"structured"`
+	}
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter)
+
+	create := func(ctx context.Context, input *CreateInput) (*CreateOutput, error) {
+		out := &CreateOutput{}
+		out.Body.Name = input.Body.Name
+
+		return out, nil
+	}
+	Post(api, "/items", create)
+	Post(api, "/limited", create, func(r *BaseRoute) {
+		r.MaxBodyBytes = 100
+	})
+
+	compress := func(encoding string, data []byte) []byte {
+		var buf bytes.Buffer
+		var writer io.WriteCloser
+		switch encoding {
+		case "gzip":
+			writer = gzip.NewWriter(&buf)
+		case "zstd":
+			writer, _ = zstd.NewWriter(&buf)
+		default:
+			return data
+		}
+		_, _ = writer.Write(data)
+		_ = writer.Close()
+
+		return buf.Bytes()
+	}
+	serve := func(path, encoding string, data []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(compress(encoding, data)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", encoding)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	for _, encoding := range []string{"gzip", "zstd"} {
+		recorder := serve("/items", encoding, []byte(`{"name":"compressed"}`))
+		assert.Equal(t, http.StatusOK, recorder.Code, encoding)
+		assert.JSONEq(t, `{"name":"compressed"}`, recorder.Body.String(), encoding)
+	}
+
+	// The size limit applies to the decompressed body
+	large, err := cbor.Marshal(map[string]string{"name": strings.Repeat("a", 1000)})
+	require.NoError(t, err)
+	require.Less(t, len(compress("gzip", large)), 100)
+	req := httptest.NewRequest(http.MethodPost, "/limited", bytes.NewReader(compress("gzip", large)))
+	req.Header.Set("Content-Type", "application/cbor")
+	req.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+	// Unsupported and invalid encodings are rejected
+	recorder = serve("/items", "compress", []byte(`{"name":"x"}`))
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+	assert.Equal(t, "gzip, deflate, zstd", recorder.Header().Get("Accept-Encoding"))
+
+	recorder = serve("/items", "br", []byte(`{"name":"x"}`))
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,110 @@
+package conditional
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/talav/zorya"
+)
+
+// ETagger is implemented by output structs that know the ETag of the resource
+// they return, e.g. from a version stored with it. Zorya sets the ETag header
+// from it, quoting the value if needed.
+type ETagger interface {
+	ETag() string
+}
+
+// LastModifier is implemented by output structs that know when the resource
+// they return was last modified. Zorya sets the Last-Modified header from it.
+type LastModifier interface {
+	LastModified() time.Time
+}
+
+// Auto enables automatic conditional GET handling for a route. Responses
+// without an ETag (from an ETag header field or ETagger) get one computed by
+// hashing the marshaled body, and GET and HEAD requests whose If-None-Match or
+// If-Modified-Since header matches the response are answered with 304 Not
+// Modified.
+//
+// The handler still runs for every request; use CheckPreconditions to skip
+// loading the resource when its ETag is known upfront. The response is
+// buffered, so Auto must not be used for streaming bodies.
+//
+// Example:
+//
+//	zorya.Get(api, "/users/{id}", getUser, conditional.Auto())
+func Auto() func(*zorya.BaseRoute) {
+	return func(r *zorya.BaseRoute) {
+		r.Middlewares = append(r.Middlewares, Middleware)
+	}
+}
+
+// Middleware handles conditional GET and HEAD requests as described for Auto.
+// Add it with API.UseMiddleware to enable it for all routes.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		rec := &bufferedResponse{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		header := w.Header()
+		if rec.status == http.StatusOK {
+			if header.Get("ETag") == "" && rec.body.Len() > 0 {
+				header.Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(rec.body.Bytes())))
+			}
+
+			if notModified(r, header) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+		}
+
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(rec.body.Bytes())
+	})
+}
+
+// notModified reports whether the request's If-None-Match or, without it,
+// If-Modified-Since header matches the response validators.
+func notModified(r *http.Request, header http.Header) bool {
+	ifModifiedSince, _ := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	lastModified, _ := http.ParseTime(header.Get("Last-Modified"))
+
+	err := CheckPreconditions(nil, r.Header.Values("If-None-Match"), ifModifiedSince, time.Time{}, header.Get("ETag"), lastModified, false)
+
+	return err != nil && err.GetStatus() == http.StatusNotModified
+}
+
+// bufferedResponse buffers the status and body of a response, writing headers
+// to the underlying writer.
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.wrote {
+		return
+	}
+	b.status = status
+	b.wrote = true
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	b.wrote = true
+
+	return b.body.Write(data)
+}
@@ -1,5 +1,6 @@
 // Package conditional provides utilities for HTTP conditional requests using
-// If-Match, If-None-Match, If-Modified-Since, and If-Unmodified-Since headers.
+// If-Match, If-None-Match, If-Modified-Since, If-Unmodified-Since and If-Range
+// headers.
 //
 // Usage:
 //
@@ -25,25 +26,21 @@
 //
 //			return &UserOutput{User: user}, nil
 //		}
+//
+// Alternatively, enable Auto on a route to compute ETags from the response
+// body and answer conditional GET requests with 304 Not Modified:
+//
+//	zorya.Get(api, "/users/{id}", getUser, conditional.Auto())
 package conditional
 
 import (
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/talav/zorya"
+	"github.com/talav/zorya/internal/etag"
 )
 
-// trimETag removes quotes and W/ prefix from ETag values for comparison.
-func trimETag(value string) string {
-	if strings.HasPrefix(value, "W/") && len(value) > 2 {
-		value = value[2:]
-	}
-
-	return strings.Trim(value, "\"")
-}
-
 // Params represents conditional request headers. Embed this struct in your input
 // struct to enable conditional request support.
 //
@@ -58,18 +55,21 @@ type Params struct {
 	IfNoneMatch       []string  `schema:"If-None-Match,location=header"`
 	IfModifiedSince   time.Time `schema:"If-Modified-Since,location=header"`
 	IfUnmodifiedSince time.Time `schema:"If-Unmodified-Since,location=header"`
+	IfRange           string    `schema:"If-Range,location=header"`
 }
 
 // HasConditionalParams returns true if any conditional request headers are present.
 func (p *Params) HasConditionalParams() bool {
-	return len(p.IfMatch) > 0 || len(p.IfNoneMatch) > 0 || !p.IfModifiedSince.IsZero() || !p.IfUnmodifiedSince.IsZero()
+	return len(p.IfMatch) > 0 || len(p.IfNoneMatch) > 0 || !p.IfModifiedSince.IsZero() || !p.IfUnmodifiedSince.IsZero() || p.IfRange != ""
 }
 
 // CheckPreconditions validates conditional request headers against the current
 // resource state. Returns an error if preconditions fail, nil otherwise.
 //
-// For read requests (GET, HEAD), returns 304 Not Modified if conditions fail.
-// For write requests (POST, PUT, PATCH, DELETE), returns 412 Precondition Failed.
+// A failed If-Match or If-Unmodified-Since returns 412 Precondition Failed.
+// A matching If-None-Match or an unmodified If-Modified-Since returns 304 Not
+// Modified for read requests (GET, HEAD); for write requests (POST, PUT, PATCH,
+// DELETE), If-None-Match returns 412 and If-Modified-Since is ignored.
 func (p *Params) CheckPreconditions(currentETag string, currentModified time.Time, isWrite bool) zorya.StatusError {
 	return CheckPreconditions(
 		p.IfMatch,
@@ -82,128 +82,33 @@ func (p *Params) CheckPreconditions(currentETag string, currentModified time.Tim
 	)
 }
 
-// checkIfNoneMatch validates If-None-Match header.
-func checkIfNoneMatch(ifNoneMatch []string, currentETag string, isWrite bool) (bool, []error) {
-	var errs []error
-	failed := false
-
-	foundMsg := "found no existing resource"
-	if currentETag != "" {
-		foundMsg = "found resource with ETag " + currentETag
-	}
-
-	for _, match := range ifNoneMatch {
-		trimmed := trimETag(match)
-		if trimmed == currentETag || (trimmed == "*" && currentETag != "") {
-			if isWrite {
-				errs = append(errs, &zorya.ErrorDetail{
-					Code:     "precondition_failed",
-					Message:  "If-None-Match: " + match + " precondition failed, " + foundMsg,
-					Location: "headers.If-None-Match",
-				})
-			}
-			failed = true
-		}
-	}
-
-	return failed, errs
-}
-
-// checkIfMatch validates If-Match header.
-func checkIfMatch(ifMatch []string, currentETag string, isWrite bool) (bool, []error) {
-	var errs []error
-	failed := false
-
-	if len(ifMatch) == 0 {
-		return false, nil
-	}
-
-	foundMsg := "found no existing resource"
-	if currentETag != "" {
-		foundMsg = "found resource with ETag " + currentETag
-	}
-
-	found := false
-	for _, match := range ifMatch {
-		if trimETag(match) == currentETag {
-			found = true
-
-			break
-		}
-	}
-
-	if !found {
-		if isWrite {
-			errs = append(errs, &zorya.ErrorDetail{
-				Code:     "precondition_failed",
-				Message:  "If-Match precondition failed, " + foundMsg,
-				Location: "headers.If-Match",
-			})
-		}
-		failed = true
-	}
-
-	return failed, errs
-}
-
-// checkIfModifiedSince validates If-Modified-Since header.
-func checkIfModifiedSince(ifModifiedSince time.Time, currentModified time.Time, isWrite bool) (bool, []error) {
-	var errs []error
-	failed := false
-
-	if ifModifiedSince.IsZero() {
-		return false, nil
-	}
-
-	if !currentModified.After(ifModifiedSince) {
-		if isWrite {
-			errs = append(errs, &zorya.ErrorDetail{
-				Code:     "not_modified",
-				Message:  "If-Modified-Since: " + ifModifiedSince.Format(http.TimeFormat) + " precondition failed, resource was modified at " + currentModified.Format(http.TimeFormat),
-				Location: "headers.If-Modified-Since",
-			})
-		}
-		failed = true
-	}
-
-	return failed, errs
-}
-
-// checkIfUnmodifiedSince validates If-Unmodified-Since header.
-func checkIfUnmodifiedSince(ifUnmodifiedSince time.Time, currentModified time.Time, isWrite bool) (bool, []error) {
-	var errs []error
-	failed := false
-
-	if ifUnmodifiedSince.IsZero() {
-		return false, nil
-	}
-
-	if currentModified.After(ifUnmodifiedSince) {
-		if isWrite {
-			errs = append(errs, &zorya.ErrorDetail{
-				Code:     "precondition_failed",
-				Message:  "If-Unmodified-Since: " + ifUnmodifiedSince.Format(http.TimeFormat) + " precondition failed, resource was modified at " + currentModified.Format(http.TimeFormat),
-				Location: "headers.If-Unmodified-Since",
-			})
-		}
-		failed = true
-	}
-
-	return failed, errs
+// RangeApplies reports whether a Range request should be served as partial
+// content, evaluating If-Range against the current resource state. See
+// CheckIfRange.
+func (p *Params) RangeApplies(currentETag string, currentModified time.Time) bool {
+	return CheckIfRange(p.IfRange, currentETag, currentModified)
 }
 
 // CheckPreconditions validates conditional request headers against the current
 // resource state. Returns an error if preconditions fail, nil otherwise.
 //
-// For read requests (GET, HEAD), returns 304 Not Modified if conditions fail.
-// For write requests (POST, PUT, PATCH, DELETE), returns 412 Precondition Failed.
+// The headers are evaluated in the order of RFC 9110 13.2.2: If-Match, or
+// If-Unmodified-Since without it, then If-None-Match, or If-Modified-Since
+// without it. If-Match uses the strong comparison and If-None-Match the weak
+// comparison. Date conditions are ignored if currentModified is zero.
+//
+// A failed If-Match or If-Unmodified-Since returns 412 Precondition Failed.
+// A matching If-None-Match or an unmodified If-Modified-Since returns 304 Not
+// Modified for read requests (GET, HEAD); for write requests (POST, PUT, PATCH,
+// DELETE), If-None-Match returns 412 and If-Modified-Since is ignored.
+// Malformed entity tag lists return 400 Bad Request.
 //
 // Parameters:
 //   - ifMatch: If-Match header values (parsed by schema)
 //   - ifNoneMatch: If-None-Match header values (parsed by schema)
 //   - ifModifiedSince: If-Modified-Since header value (parsed by schema)
 //   - ifUnmodifiedSince: If-Unmodified-Since header value (parsed by schema)
-//   - currentETag: Current resource ETag (empty if resource doesn't exist)
+//   - currentETag: Current resource ETag, quoted or not (empty if resource doesn't exist)
 //   - currentModified: Current resource last modified time
 //   - isWrite: True for write requests (POST, PUT, PATCH, DELETE)
 func CheckPreconditions(
@@ -215,44 +120,104 @@ func CheckPreconditions(
 	currentModified time.Time,
 	isWrite bool,
 ) zorya.StatusError {
-	var allErrs []error
-	failed := false
+	current, exists := etag.ParseCurrent(currentETag)
 
-	failedNoneMatch, errs := checkIfNoneMatch(ifNoneMatch, currentETag, isWrite)
-	if failedNoneMatch {
-		failed = true
-		allErrs = append(allErrs, errs...)
+	foundMsg := "found no existing resource"
+	if exists {
+		foundMsg = "found resource with ETag " + current.String()
 	}
 
-	failedMatch, errs := checkIfMatch(ifMatch, currentETag, isWrite)
-	if failedMatch {
-		failed = true
-		allErrs = append(allErrs, errs...)
+	// If-Match, or If-Unmodified-Since without it
+	if len(ifMatch) > 0 {
+		matched, err := matchETagList(ifMatch, current, exists, ETag.StrongMatch)
+		if err != nil {
+			return zorya.Error400BadRequest("invalid If-Match header", &zorya.ErrorDetail{
+				Code:     "invalid_header",
+				Message:  err.Error(),
+				Location: "headers.If-Match",
+			})
+		}
+		if !matched {
+			return preconditionFailed(&zorya.ErrorDetail{
+				Code:     "precondition_failed",
+				Message:  "If-Match precondition failed, " + foundMsg,
+				Location: "headers.If-Match",
+			})
+		}
+	} else if !ifUnmodifiedSince.IsZero() && !currentModified.IsZero() &&
+		currentModified.Truncate(time.Second).After(ifUnmodifiedSince) {
+		return preconditionFailed(&zorya.ErrorDetail{
+			Code:     "precondition_failed",
+			Message:  "If-Unmodified-Since: " + ifUnmodifiedSince.Format(http.TimeFormat) + " precondition failed, resource was modified at " + currentModified.Format(http.TimeFormat),
+			Location: "headers.If-Unmodified-Since",
+		})
+	}
+
+	// If-None-Match, or If-Modified-Since without it
+	if len(ifNoneMatch) > 0 {
+		matched, err := matchETagList(ifNoneMatch, current, exists, ETag.WeakMatch)
+		if err != nil {
+			return zorya.Error400BadRequest("invalid If-None-Match header", &zorya.ErrorDetail{
+				Code:     "invalid_header",
+				Message:  err.Error(),
+				Location: "headers.If-None-Match",
+			})
+		}
+		if !matched {
+			return nil
+		}
+		if isWrite {
+			return preconditionFailed(&zorya.ErrorDetail{
+				Code:     "precondition_failed",
+				Message:  "If-None-Match precondition failed, " + foundMsg,
+				Location: "headers.If-None-Match",
+			})
+		}
+
+		return zorya.Status304NotModified()
 	}
 
-	failedModified, errs := checkIfModifiedSince(ifModifiedSince, currentModified, isWrite)
-	if failedModified {
-		failed = true
-		allErrs = append(allErrs, errs...)
+	if !isWrite && !ifModifiedSince.IsZero() && !currentModified.IsZero() &&
+		!currentModified.Truncate(time.Second).After(ifModifiedSince) {
+		return zorya.Status304NotModified()
 	}
 
-	failedUnmodified, errs := checkIfUnmodifiedSince(ifUnmodifiedSince, currentModified, isWrite)
-	if failedUnmodified {
-		failed = true
-		allErrs = append(allErrs, errs...)
+	return nil
+}
+
+// CheckIfRange reports whether a Range request should be served as partial
+// content: true if the If-Range header is empty, its entity tag strongly
+// matches the current ETag, or its date equals the current modification time.
+// Otherwise the full representation must be sent (RFC 9110 13.1.5).
+func CheckIfRange(ifRange string, currentETag string, currentModified time.Time) bool {
+	return etag.IfRangeMatches(ifRange, currentETag, currentModified)
+}
+
+// matchETagList reports whether an If-Match or If-None-Match header matches
+// the current ETag using the given comparison. "*" matches if the resource
+// exists.
+func matchETagList(values []string, current ETag, exists bool, match func(ETag, ETag) bool) (bool, error) {
+	etags, wildcard, err := ParseETagList(values...)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+	if wildcard {
+		return true, nil
 	}
 
-	if failed {
-		if isWrite {
-			return zorya.NewError(
-				http.StatusPreconditionFailed,
-				http.StatusText(http.StatusPreconditionFailed),
-				allErrs...,
-			)
+	for _, tag := range etags {
+		if match(tag, current) {
+			return true, nil
 		}
-
-		return zorya.Status304NotModified()
 	}
 
-	return nil
+	return false, nil
+}
+
+// preconditionFailed returns a 412 Precondition Failed error.
+func preconditionFailed(detail *zorya.ErrorDetail) zorya.StatusError {
+	return zorya.NewError(http.StatusPreconditionFailed, http.StatusText(http.StatusPreconditionFailed), detail)
 }
@@ -0,0 +1,169 @@
+package conditional_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/conditional"
+	"github.com/talav/zorya/zoryatest"
+)
+
+type Item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ItemOutput struct {
+	Body Item `body:"structured"`
+}
+
+type VersionedOutput struct {
+	version  int
+	modified time.Time
+	Body     Item `body:"structured"`
+}
+
+func (o *VersionedOutput) ETag() string {
+	return "v" + strconv.Itoa(o.version)
+}
+
+func (o *VersionedOutput) LastModified() time.Time {
+	return o.modified
+}
+
+func TestAuto(t *testing.T) {
+	api := zoryatest.New(t)
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	zorya.Get(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*ItemOutput, error) {
+		return &ItemOutput{Body: Item{ID: 1, Name: "first"}}, nil
+	}, conditional.Auto())
+	zorya.Get(api, "/versioned", func(ctx context.Context, _ *struct{}) (*VersionedOutput, error) {
+		return &VersionedOutput{version: 2, modified: modified, Body: Item{ID: 2, Name: "second"}}, nil
+	}, conditional.Auto())
+
+	// The ETag is computed from the body
+	resp := api.Get("/items/1")
+	require.Equal(t, http.StatusOK, resp.Code)
+	etag := resp.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{64}"$`, etag)
+
+	resp = api.Get("/items/1", "If-None-Match: "+etag)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Empty(t, resp.Body.String())
+	assert.Equal(t, etag, resp.Header().Get("ETag"))
+
+	// Weak comparison matches the weakened ETag of a compressed response
+	resp = api.Get("/items/1", `If-None-Match: "other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+
+	resp = api.Get("/items/1", `If-None-Match: "other"`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// Validators provided by the output are used instead
+	resp = api.Get("/versioned")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"v2"`, resp.Header().Get("ETag"))
+	assert.Equal(t, modified.Format(http.TimeFormat), resp.Header().Get("Last-Modified"))
+
+	resp = api.Get("/versioned", `If-None-Match: "v2"`)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+
+	resp = api.Get("/versioned", "If-Modified-Since: "+modified.Format(http.TimeFormat))
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+
+	resp = api.Get("/versioned", "If-Modified-Since: "+modified.Add(-time.Hour).Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// If-None-Match takes precedence over If-Modified-Since
+	resp = api.Get("/versioned", `If-None-Match: "v1"`, "If-Modified-Since: "+modified.Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestParseETagList(t *testing.T) {
+	etags, wildcard, err := conditional.ParseETagList(`"a", W/"b"`, `"c,d"`)
+	require.NoError(t, err)
+	assert.False(t, wildcard)
+	assert.Equal(t, []conditional.ETag{{Tag: "a"}, {Tag: "b", Weak: true}, {Tag: "c,d"}}, etags)
+	assert.Equal(t, `W/"b"`, etags[1].String())
+
+	_, wildcard, err = conditional.ParseETagList("*")
+	require.NoError(t, err)
+	assert.True(t, wildcard)
+
+	_, _, err = conditional.ParseETagList(`"a", b`)
+	require.ErrorIs(t, err, conditional.ErrInvalidETag)
+
+	strong, weak := conditional.ETag{Tag: "a"}, conditional.ETag{Tag: "a", Weak: true}
+	assert.True(t, strong.StrongMatch(strong))
+	assert.False(t, strong.StrongMatch(weak))
+	assert.True(t, strong.WeakMatch(weak))
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	before, after := modified.Add(-time.Hour), modified.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		params conditional.Params
+		etag   string
+		write  bool
+		status int
+	}{
+		{"no conditions", conditional.Params{}, `"v1"`, false, 0},
+		{"if-match strong", conditional.Params{IfMatch: []string{`"v0", "v1"`}}, `"v1"`, true, 0},
+		{"if-match unquoted current", conditional.Params{IfMatch: []string{`"v1"`}}, `v1`, true, 0},
+		{"if-match weak fails", conditional.Params{IfMatch: []string{`W/"v1"`}}, `"v1"`, true, http.StatusPreconditionFailed},
+		{"if-match weak current fails", conditional.Params{IfMatch: []string{`"v1"`}}, `W/"v1"`, true, http.StatusPreconditionFailed},
+		{"if-match on read fails", conditional.Params{IfMatch: []string{`"v0"`}}, `"v1"`, false, http.StatusPreconditionFailed},
+		{"if-match star", conditional.Params{IfMatch: []string{"*"}}, `"v1"`, true, 0},
+		{"if-match star without resource", conditional.Params{IfMatch: []string{"*"}}, "", true, http.StatusPreconditionFailed},
+		{"if-match invalid", conditional.Params{IfMatch: []string{"v1"}}, `"v1"`, true, http.StatusBadRequest},
+		{"if-match beats if-unmodified-since", conditional.Params{IfMatch: []string{`"v1"`}, IfUnmodifiedSince: before}, `"v1"`, true, 0},
+		{"if-unmodified-since fails", conditional.Params{IfUnmodifiedSince: before}, `"v1"`, true, http.StatusPreconditionFailed},
+		{"if-unmodified-since passes", conditional.Params{IfUnmodifiedSince: modified}, `"v1"`, true, 0},
+		{"if-none-match weak", conditional.Params{IfNoneMatch: []string{`W/"v1"`}}, `"v1"`, false, http.StatusNotModified},
+		{"if-none-match on write", conditional.Params{IfNoneMatch: []string{"*"}}, `"v1"`, true, http.StatusPreconditionFailed},
+		{"if-none-match star without resource", conditional.Params{IfNoneMatch: []string{"*"}}, "", true, 0},
+		{"if-none-match beats if-modified-since", conditional.Params{IfNoneMatch: []string{`"v0"`}, IfModifiedSince: after}, `"v1"`, false, 0},
+		{"if-modified-since not modified", conditional.Params{IfModifiedSince: modified}, `"v1"`, false, http.StatusNotModified},
+		{"if-modified-since modified", conditional.Params{IfModifiedSince: before}, `"v1"`, false, 0},
+		{"if-modified-since ignored on write", conditional.Params{IfModifiedSince: after}, `"v1"`, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.CheckPreconditions(tt.etag, modified, tt.write)
+			if tt.status == 0 {
+				assert.NoError(t, err)
+
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.status, err.GetStatus())
+		})
+	}
+
+	// Date conditions are ignored without a modification time
+	params := conditional.Params{IfModifiedSince: modified}
+	assert.NoError(t, params.CheckPreconditions(`"v1"`, time.Time{}, false))
+}
+
+func TestCheckIfRange(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.True(t, conditional.CheckIfRange("", `"v1"`, modified))
+	assert.True(t, conditional.CheckIfRange(`"v1"`, `"v1"`, modified))
+	assert.False(t, conditional.CheckIfRange(`"v0"`, `"v1"`, modified))
+	assert.False(t, conditional.CheckIfRange(`W/"v1"`, `W/"v1"`, modified))
+	assert.True(t, conditional.CheckIfRange(modified.Format(http.TimeFormat), `"v1"`, modified))
+	assert.False(t, conditional.CheckIfRange(modified.Add(-time.Second).Format(http.TimeFormat), `"v1"`, modified))
+	assert.False(t, conditional.CheckIfRange("garbage", `"v1"`, modified))
+}
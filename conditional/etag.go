@@ -0,0 +1,23 @@
+package conditional
+
+import (
+	"github.com/talav/zorya/internal/etag"
+)
+
+// ETag is an entity tag (RFC 9110 8.8.3).
+type ETag = etag.ETag
+
+// ErrInvalidETag is returned when an entity tag cannot be parsed.
+var ErrInvalidETag = etag.ErrInvalidETag
+
+// ParseETag parses an entity tag such as "xyz" or W/"xyz".
+func ParseETag(s string) (ETag, error) {
+	return etag.Parse(s)
+}
+
+// ParseETagList parses the values of an If-Match or If-None-Match header.
+// Each value may hold a comma-separated list of entity tags. It reports wildcard
+// as true if the list holds "*", which matches any current representation.
+func ParseETagList(values ...string) (etags []ETag, wildcard bool, err error) {
+	return etag.ParseList(values...)
+}
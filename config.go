@@ -7,6 +7,16 @@ type Config struct {
 	// The path is used as-is, with no automatic suffix or extension handling.
 	OpenAPIPath string
 
+	// OpenAPIYAMLPath is the exact path to the OpenAPI spec encoded as YAML.
+	// Clients can also request YAML from OpenAPIPath with the Accept header.
+	OpenAPIYAMLPath string
+
+	// OpenAPI30Path and OpenAPI30YAMLPath are the exact paths to the spec
+	// downgraded to OpenAPI 3.0.3, as JSON and YAML, for tools that do not
+	// support OpenAPI 3.1. QUERY and custom method operations are left out.
+	OpenAPI30Path     string
+	OpenAPI30YAMLPath string
+
 	// DocsPath is the path to the API documentation. If set to `/docs` it will
 	// allow clients to get `/docs` to view the documentation in a browser. If
 	// you wish to provide your own documentation renderer, you can leave this
@@ -14,13 +24,16 @@ type Config struct {
 	DocsPath string
 
 	// SchemasPath is the path to the API schemas. If set to `/schemas` it will
-	// allow clients to get `/schemas/{schema}` to view the schema in a browser
+	// allow clients to get `/schemas/{schema}.json` to view the schema in a browser
 	// or for use in editors like VSCode to provide autocomplete & validation.
+	// Responses whose body maps to a schema link to it with a
+	// `Link: <...>; rel="describedby"` header once the spec is generated.
 	SchemasPath string
 
 	// DefaultFormat specifies the default content type to use when the client
-	// does not specify one. If unset, the default type will be randomly
-	// chosen from the keys of `Formats`.
+	// does not specify one. WithDefaultFormat takes precedence over it. If
+	// unset, application/json is used when registered, otherwise the first
+	// registered format in alphabetical order.
 	DefaultFormat string
 
 	// NoFormatFallback disables the fallback to application/json (if available)
@@ -31,7 +44,8 @@ type Config struct {
 
 // DefaultConfig returns a default configuration for a new API. It is a good
 // starting point for creating your own configuration. It supports the JSON
-// format out of the box. The `/openapi.json`, `/docs`, and `/schemas` paths
+// format out of the box. The `/openapi.json` (along with `/openapi.yaml`,
+// `/openapi-3.0.json` and `/openapi-3.0.yaml`), `/docs`, and `/schemas` paths
 // are set up to serve the OpenAPI spec, docs UI, and schemas respectively.
 //
 //	// Create and customize the config (if desired).
@@ -42,10 +56,13 @@ type Config struct {
 //	api := zorya.NewAPI(router, zorya.WithConfig(config))
 func DefaultConfig() *Config {
 	return &Config{
-		OpenAPIPath:      "/openapi.json",
-		DocsPath:         "/docs",
-		SchemasPath:      "/schemas",
-		DefaultFormat:    "application/json",
-		NoFormatFallback: false,
+		OpenAPIPath:       "/openapi.json",
+		OpenAPIYAMLPath:   "/openapi.yaml",
+		OpenAPI30Path:     "/openapi-3.0.json",
+		OpenAPI30YAMLPath: "/openapi-3.0.yaml",
+		DocsPath:          "/docs",
+		SchemasPath:       "/schemas",
+		DefaultFormat:     "application/json",
+		NoFormatFallback:  false,
 	}
 }
@@ -0,0 +1,358 @@
+package zorya
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/talav/zorya/internal/etag"
+)
+
+var contentBodyType = reflect.TypeFor[Content]()
+
+// errRangeNotSatisfiable is returned when none of the requested ranges overlap
+// the content.
+var errRangeNotSatisfiable = errors.New("none of the requested ranges is satisfiable")
+
+// Content is a response body served from an io.ReadSeeker, such as an
+// *os.File, with support for Range requests: clients can resume downloads or
+// fetch parts of the content, which are sent as 206 Partial Content (as
+// multipart/byteranges for several ranges). If-Range is evaluated against the
+// response's ETag header and ModTime. Content bodies are not compressed.
+//
+// Example:
+//
+//	type DownloadOutput struct {
+//		ETag string `schema:"ETag,location=header"`
+//		Body zorya.Content
+//	}
+//
+//	zorya.Get(api, "/files/{name}", func(ctx context.Context, input *DownloadInput) (*DownloadOutput, error) {
+//		f, err := os.Open(filepath.Join(dir, input.Name))
+//		if err != nil {
+//			return nil, zorya.Error404NotFound("file not found")
+//		}
+//		info, _ := f.Stat()
+//
+//		return &DownloadOutput{Body: zorya.Content{Reader: f, Size: info.Size(), ModTime: info.ModTime()}}, nil
+//	})
+type Content struct {
+	// Reader provides the content. It is closed after the response is
+	// written if it implements io.Closer.
+	Reader io.ReadSeeker
+
+	// Size is the content length in bytes. If 0, it is determined by seeking
+	// to the end of Reader.
+	Size int64
+
+	// ModTime is the last modification time, sent as Last-Modified unless the
+	// output sets it. Zero omits it.
+	ModTime time.Time
+
+	// ContentType is the media type of the content. If empty, it is detected
+	// from the first bytes of the content.
+	ContentType string
+}
+
+// hasContentBody reports whether the output struct's body is a Content.
+func hasContentBody(outputType reflect.Type) bool {
+	field, ok := outputType.FieldByName("Body")
+
+	return ok && field.Type == contentBodyType
+}
+
+// byteRange is a range of content bytes.
+type byteRange struct {
+	start, length int64
+}
+
+// contentRange returns the Content-Range header value of the range.
+func (br byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", br.start, br.start+br.length-1, size)
+}
+
+// writeContent sends the content, or the ranges requested by a GET or HEAD
+// request as 206 Partial Content. Unsatisfiable ranges are answered with 416
+// Range Not Satisfiable.
+func writeContent(api API, r *http.Request, w http.ResponseWriter, content Content, status int) {
+	if closer, ok := content.Reader.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	size, ct, err := contentInfo(content)
+	if err != nil {
+		WriteErr(api, r, w, http.StatusInternalServerError, "failed to read content", err)
+
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", ct)
+	header.Set("Accept-Ranges", "bytes")
+	if !content.ModTime.IsZero() && header.Get("Last-Modified") == "" {
+		header.Set("Last-Modified", content.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	var ranges []byteRange
+	if status == http.StatusOK && (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+		etag.IfRangeMatches(r.Header.Get("If-Range"), header.Get("ETag"), content.ModTime) {
+		ranges, err = parseRange(r.Header.Get("Range"), size)
+		if err != nil {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			WriteErr(api, r, w, http.StatusRequestedRangeNotSatisfiable, "Range Not Satisfiable", err)
+
+			return
+		}
+	}
+
+	var total int64
+	for _, br := range ranges {
+		total += br.length
+	}
+	if total > size {
+		// Overlapping ranges would send more than the whole content
+		ranges = nil
+	}
+
+	send := r.Method != http.MethodHead
+	switch len(ranges) {
+	case 0:
+		header.Set("Content-Length", strconv.FormatInt(size, 10))
+		w.WriteHeader(status)
+		if send {
+			_, _ = io.CopyN(w, content.Reader, size)
+		}
+
+	case 1:
+		header.Set("Content-Range", ranges[0].contentRange(size))
+		header.Set("Content-Length", strconv.FormatInt(ranges[0].length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+		if send {
+			_ = copyRange(w, content.Reader, ranges[0])
+		}
+
+	default:
+		parts := multipart.NewWriter(w)
+		header.Set("Content-Type", "multipart/byteranges; boundary="+parts.Boundary())
+		header.Del("Content-Length")
+		w.WriteHeader(http.StatusPartialContent)
+		if !send {
+			return
+		}
+
+		for _, br := range ranges {
+			part, err := parts.CreatePart(textproto.MIMEHeader{
+				"Content-Type":  {ct},
+				"Content-Range": {br.contentRange(size)},
+			})
+			if err != nil || copyRange(part, content.Reader, br) != nil {
+				return
+			}
+		}
+		_ = parts.Close()
+	}
+}
+
+// contentInfo returns the size and media type of the content, determining
+// them from the reader if they are not set.
+func contentInfo(content Content) (int64, string, error) {
+	if content.Reader == nil {
+		return 0, "", errors.New("content has no reader")
+	}
+
+	size := content.Size
+	if size == 0 {
+		end, err := content.Reader.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, "", err
+		}
+		size = end
+	}
+
+	ct := content.ContentType
+	if ct == "" {
+		if _, err := content.Reader.Seek(0, io.SeekStart); err != nil {
+			return 0, "", err
+		}
+		var buf [512]byte
+		n, _ := io.ReadFull(content.Reader, buf[:])
+		ct = http.DetectContentType(buf[:n])
+	}
+
+	if _, err := content.Reader.Seek(0, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+
+	return size, ct, nil
+}
+
+// copyRange copies the range of the content to w.
+func copyRange(w io.Writer, reader io.ReadSeeker, br byteRange) error {
+	if _, err := reader.Seek(br.start, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.CopyN(w, reader, br.length)
+
+	return err
+}
+
+// parseRange parses a Range header value (e.g. "bytes=0-99,200-") into the
+// ranges that overlap content of the given size. Returns nil if the header is
+// empty or uses another unit, and errRangeNotSatisfiable if the header is
+// invalid or no range overlaps the content.
+func parseRange(header string, size int64) ([]byteRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, nil
+	}
+
+	var ranges []byteRange
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, errRangeNotSatisfiable
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var br byteRange
+		if first == "" {
+			// Suffix range: the last bytes of the content
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errRangeNotSatisfiable
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			n = min(n, size)
+			br = byteRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errRangeNotSatisfiable
+			}
+			if start >= size {
+				continue
+			}
+			end := size - 1
+			if last != "" {
+				if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+					return nil, errRangeNotSatisfiable
+				}
+				end = min(end, size-1)
+			}
+			br = byteRange{start: start, length: end - start + 1}
+		}
+		ranges = append(ranges, br)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errRangeNotSatisfiable
+	}
+
+	return ranges, nil
+}
+
+// setContentResponse documents the response of a route whose output Body is a
+// Content: the binary body with Accept-Ranges, the 206 Partial Content response
+// and the 416 Range Not Satisfiable error, along with the Range and If-Range
+// request headers.
+func setContentResponse(operation map[string]any, route *BaseRoute) {
+	status := route.DefaultStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	responses, _ := operation["responses"].(map[string]any)
+	if responses == nil {
+		responses = map[string]any{}
+		operation["responses"] = responses
+	}
+
+	binary := map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+	stringHeader := func(description string) map[string]any {
+		return map[string]any{"description": description, "schema": map[string]any{"type": "string"}}
+	}
+	acceptRanges := map[string]any{
+		"description": "Range units supported by the operation.",
+		"schema":      map[string]any{"type": "string", "enum": []any{"bytes"}},
+	}
+
+	response, _ := responses[strconv.Itoa(status)].(map[string]any)
+	if response == nil {
+		response = map[string]any{"description": http.StatusText(status)}
+		responses[strconv.Itoa(status)] = response
+	}
+	response["content"] = map[string]any{contentTypeOctetStream: binary}
+	headers, _ := response["headers"].(map[string]any)
+	if headers == nil {
+		headers = map[string]any{}
+		response["headers"] = headers
+	}
+	headers["Accept-Ranges"] = acceptRanges
+	headers["Last-Modified"] = stringHeader("Last modification time of the content.")
+
+	if status != http.StatusOK {
+		return
+	}
+
+	responses[strconv.Itoa(http.StatusPartialContent)] = map[string]any{
+		"description": http.StatusText(http.StatusPartialContent),
+		"headers": map[string]any{
+			"Accept-Ranges": acceptRanges,
+			"Content-Range": stringHeader("Range of the content sent, for a single range."),
+		},
+		"content": map[string]any{
+			contentTypeOctetStream: binary,
+			"multipart/byteranges": binary,
+		},
+	}
+
+	// 416 is sent in the error model, like the 500 response
+	notSatisfiable := map[string]any{
+		"description": http.StatusText(http.StatusRequestedRangeNotSatisfiable),
+		"headers": map[string]any{
+			"Content-Range": stringHeader("Size of the content, as bytes */size."),
+		},
+	}
+	if internalError, ok := responses[strconv.Itoa(http.StatusInternalServerError)].(map[string]any); ok {
+		if content, ok := internalError["content"].(map[string]any); ok {
+			notSatisfiable["content"] = maps.Clone(content)
+		}
+	}
+	responses[strconv.Itoa(http.StatusRequestedRangeNotSatisfiable)] = notSatisfiable
+
+	parameters, _ := operation["parameters"].([]any)
+	for name, description := range map[string]string{
+		"Range":    "Byte ranges to send, e.g. bytes=0-1023.",
+		"If-Range": "Send the ranges only if the content still has this ETag or modification time.",
+	} {
+		exists := false
+		for _, param := range parameters {
+			if p, ok := param.(map[string]any); ok && p["in"] == "header" && strings.EqualFold(fmt.Sprint(p["name"]), name) {
+				exists = true
+			}
+		}
+		if !exists {
+			parameters = append(parameters, map[string]any{
+				"name":        name,
+				"in":          "header",
+				"description": description,
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+	}
+	operation["parameters"] = parameters
+}
@@ -0,0 +1,127 @@
+package zorya
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentRanges(t *testing.T) {
+	type DownloadOutput struct {
+		ETag string `schema:"ETag,location=header"`
+		Body Content
+	}
+
+	data := []byte("0123456789abcdefghij")
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithCompression(&Compression{MinSize: -1}))
+
+	Get(api, "/files/{name}", func(ctx context.Context, _ *struct{}) (*DownloadOutput, error) {
+		return &DownloadOutput{ETag: `"v1"`, Body: Content{
+			Reader:      bytes.NewReader(data),
+			ModTime:     modified,
+			ContentType: "text/plain",
+		}}, nil
+	})
+
+	serve := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/files/data.txt", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// Without Range the whole content is sent, uncompressed
+	recorder := serve(http.MethodGet, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "20", recorder.Header().Get("Content-Length"))
+	assert.Equal(t, modified.Format(http.TimeFormat), recorder.Header().Get("Last-Modified"))
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, string(data), recorder.Body.String())
+
+	// A single range
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=5-9"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "bytes 5-9/20", recorder.Header().Get("Content-Range"))
+	assert.Equal(t, "56789", recorder.Body.String())
+
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=-3"})
+	assert.Equal(t, "bytes 17-19/20", recorder.Header().Get("Content-Range"))
+	assert.Equal(t, "hij", recorder.Body.String())
+
+	// Several ranges are sent as multipart/byteranges
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=0-1, 18-"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	mediaType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+
+	reader := multipart.NewReader(recorder.Body, params["boundary"])
+	for _, want := range []struct{ contentRange, body string }{
+		{"bytes 0-1/20", "01"},
+		{"bytes 18-19/20", "ij"},
+	} {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, want.contentRange, part.Header.Get("Content-Range"))
+		assert.Equal(t, "text/plain", part.Header.Get("Content-Type"))
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, want.body, string(body))
+	}
+
+	// Unsatisfiable ranges get a 416 error model
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=50-"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, recorder.Code)
+	assert.Equal(t, "bytes */20", recorder.Header().Get("Content-Range"))
+	var errModel ErrorModel
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &errModel))
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, errModel.Status)
+
+	// If-Range sends the whole content when the representation changed
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=0-1", "If-Range": `"v1"`})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=0-1", "If-Range": modified.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	recorder = serve(http.MethodGet, map[string]string{"Range": "bytes=0-1", "If-Range": `"v0"`})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, string(data), recorder.Body.String())
+
+	// HEAD sends the headers only
+	recorder = serve(http.MethodHead, map[string]string{"Range": "bytes=0-1"})
+	assert.Equal(t, http.StatusPartialContent, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Content-Length"))
+	assert.Empty(t, recorder.Body.String())
+
+	// The operation documents the binary body, ranges and range errors
+	spec, err := api.Spec(context.Background())
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	operation := doc["paths"].(map[string]any)["/files/{name}"].(map[string]any)["get"].(map[string]any)
+	responses := operation["responses"].(map[string]any)
+	assert.Contains(t, responses["200"].(map[string]any)["headers"], "Accept-Ranges")
+	assert.Contains(t, responses["200"].(map[string]any)["content"], "application/octet-stream")
+	assert.Contains(t, responses["206"].(map[string]any)["content"], "multipart/byteranges")
+	assert.Contains(t, responses, "416")
+}
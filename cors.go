@@ -0,0 +1,222 @@
+package zorya
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/talav/schema"
+)
+
+// CORS configures Cross-Origin Resource Sharing for an API, a group or a
+// single route. Preflight requests are answered with the methods registered
+// for the requested path, and the response headers declared in output structs
+// are exposed automatically.
+type CORS struct {
+	// AllowOrigins lists the origins allowed to make cross-origin requests,
+	// e.g. "https://app.example.com". "*" allows any origin, and a "*" in place
+	// of a subdomain allows all its subdomains (e.g. "https://*.example.com").
+	AllowOrigins []string
+
+	// AllowOriginFunc reports whether an origin not listed in AllowOrigins is
+	// allowed.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowHeaders lists the request headers allowed in cross-origin requests.
+	// If empty, the headers requested in a preflight are allowed.
+	AllowHeaders []string
+
+	// ExposeHeaders lists response headers exposed to the client in addition
+	// to the header fields of the route's output struct.
+	ExposeHeaders []string
+
+	// AllowCredentials allows requests with credentials (cookies, HTTP
+	// authentication). Browsers reject credentials for any origin, so it
+	// cannot be combined with "*" in AllowOrigins; routes with such a
+	// configuration fail to register.
+	AllowCredentials bool
+
+	// MaxAge sets how long the preflight response may be cached. Zero omits
+	// the Access-Control-Max-Age header.
+	MaxAge time.Duration
+
+	// DocumentPreflight adds the preflight OPTIONS operation to the OpenAPI
+	// spec for paths without an explicit OPTIONS route.
+	DocumentPreflight bool
+}
+
+// validate returns an error if browsers would reject the configuration.
+func (c *CORS) validate() error {
+	if c != nil && c.AllowCredentials && slices.Contains(c.AllowOrigins, "*") {
+		return errors.New(`CORS AllowOrigins "*" cannot be combined with AllowCredentials, list the allowed origins or use AllowOriginFunc`)
+	}
+
+	return nil
+}
+
+// allowsOrigin reports whether the origin may make cross-origin requests.
+func (c *CORS) allowsOrigin(origin string) bool {
+	if c == nil || origin == "" {
+		return false
+	}
+
+	for _, allowed := range c.AllowOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok &&
+			len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+			strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+			return true
+		}
+	}
+
+	return c.AllowOriginFunc != nil && c.AllowOriginFunc(origin)
+}
+
+// setAllowOrigin sets the Access-Control-Allow-Origin and credentials headers
+// for an allowed origin.
+func (c *CORS) setAllowOrigin(header http.Header, origin string) {
+	if slices.Contains(c.AllowOrigins, "*") {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// newCORSMiddleware creates middleware that adds the CORS headers to the
+// responses of the route for allowed origins, exposing the header fields of the
+// output struct along with CORS.ExposeHeaders. Returns nil if the route has no
+// CORS configuration.
+func newCORSMiddleware(cors *CORS, outputHeaders []string) Middleware {
+	if cors == nil {
+		return nil
+	}
+
+	exposed := strings.Join(slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(outputHeaders), cors.ExposeHeaders...)))), ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if cors.allowsOrigin(origin) {
+				cors.setAllowOrigin(w.Header(), origin)
+				if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isPreflight reports whether the request is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// writePreflight answers a CORS preflight request for the path with the
+// methods whose routes allow the origin. Reports false if the requested method
+// is not allowed for the origin, leaving the request to regular OPTIONS
+// handling, which does not grant access.
+func (a *api) writePreflight(w http.ResponseWriter, r *http.Request, key string) bool {
+	origin := r.Header.Get("Origin")
+	cors, methods := a.routes.preflight(key, r.Header.Get("Access-Control-Request-Method"), origin)
+	if cors == nil {
+		return false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	cors.setAllowOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+	if len(cors.AllowHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowHeaders, ", "))
+	} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		header.Set("Access-Control-Allow-Headers", requested)
+	}
+	if cors.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return true
+}
+
+// outputHeaderNames returns the names of the response headers declared by the
+// output struct's header fields.
+func outputHeaderNames(api API, outputType reflect.Type) []string {
+	structMeta, err := api.Metadata().GetStructMetadata(outputType)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for i := range structMeta.Fields {
+		schemaMeta, ok := schema.GetTagMetadata[*schema.SchemaMetadata](&structMeta.Fields[i], "schema")
+		if ok && schemaMeta.Location == schema.LocationHeader {
+			names = append(names, schemaMeta.ParamName)
+		}
+	}
+
+	return names
+}
+
+// preflightOperation returns the OpenAPI operation documenting the CORS
+// preflight of a path, with the path parameters of the given operation.
+func preflightOperation(operation map[string]any) map[string]any {
+	stringHeader := func(description string) map[string]any {
+		return map[string]any{"description": description, "schema": map[string]any{"type": "string"}}
+	}
+	headerParam := func(name, description string, required bool) map[string]any {
+		return map[string]any{"name": name, "in": "header", "description": description, "required": required, "schema": map[string]any{"type": "string"}}
+	}
+
+	parameters := []any{
+		headerParam("Origin", "Origin of the cross-origin request.", true),
+		headerParam("Access-Control-Request-Method", "Method of the cross-origin request.", true),
+		headerParam("Access-Control-Request-Headers", "Headers of the cross-origin request.", false),
+	}
+	params, _ := operation["parameters"].([]any)
+	for _, param := range params {
+		if p, ok := param.(map[string]any); ok && p["in"] == "path" {
+			parameters = append(parameters, p)
+		}
+	}
+
+	return map[string]any{
+		"summary":    "CORS preflight",
+		"parameters": parameters,
+		"responses": map[string]any{
+			"204": map[string]any{
+				"description": "Cross-origin request allowed",
+				"headers": map[string]any{
+					"Access-Control-Allow-Origin":      stringHeader("Allowed origin."),
+					"Access-Control-Allow-Methods":     stringHeader("Allowed methods."),
+					"Access-Control-Allow-Headers":     stringHeader("Allowed request headers."),
+					"Access-Control-Allow-Credentials": stringHeader("Whether credentials are allowed."),
+					"Access-Control-Max-Age": map[string]any{
+						"description": "Seconds the preflight response may be cached.",
+						"schema":      map[string]any{"type": "integer"},
+					},
+				},
+			},
+		},
+	}
+}
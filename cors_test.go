@@ -0,0 +1,143 @@
+package zorya
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS_WildcardWithCredentials(t *testing.T) {
+	invalid := &CORS{AllowOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}
+	handler := func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+		return nil, nil
+	}
+
+	// On the route
+	api := NewAPI(&testChiAdapter{router: chi.NewMux()})
+	err := Register(api, BaseRoute{Method: http.MethodGet, Path: "/items", CORS: invalid}, handler)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AllowCredentials")
+	assert.Empty(t, api.Routes(), "nothing is registered")
+
+	// On the API
+	api = NewAPI(&testChiAdapter{router: chi.NewMux()}, WithCORS(invalid))
+	require.Error(t, Register(api, BaseRoute{Method: http.MethodGet, Path: "/items"}, handler))
+	assert.Panics(t, func() { Get(api, "/items", handler) })
+
+	// Listed origins and AllowOriginFunc may be combined with credentials
+	api = NewAPI(&testChiAdapter{router: chi.NewMux()}, WithCORS(&CORS{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowOriginFunc:  func(origin string) bool { return true },
+		AllowCredentials: true,
+	}))
+	require.NoError(t, Register(api, BaseRoute{Method: http.MethodGet, Path: "/items"}, handler))
+}
+
+func TestCORS(t *testing.T) {
+	type ItemOutput struct {
+		ETag string `schema:"ETag,location=header"`
+		Body struct {
+			ID int `json:"id"`
+		} `body:"structured"`
+	}
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithCORS(&CORS{
+		AllowOrigins:      []string{"https://app.example.com", "https://*.example.org"},
+		MaxAge:            10 * time.Minute,
+		DocumentPreflight: true,
+	}))
+
+	getItem := func(ctx context.Context, _ *struct{}) (*ItemOutput, error) {
+		out := &ItemOutput{ETag: `"v1"`}
+		out.Body.ID = 1
+
+		return out, nil
+	}
+	Get(api, "/items/{id}", getItem)
+	Post(api, "/items/{id}", getItem)
+
+	admin := NewGroup(api, "/admin")
+	admin.UseCORS(&CORS{})
+	Get(admin, "/items", getItem)
+	Get(admin, "/public", getItem, func(r *BaseRoute) {
+		r.CORS = &CORS{AllowOrigins: []string{"*"}}
+	})
+
+	serve := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// Allowed origins get the CORS headers, exposing the output header fields
+	recorder := serve(http.MethodGet, "/items/1", map[string]string{"Origin": "https://app.example.com"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "ETag", recorder.Header().Get("Access-Control-Expose-Headers"))
+	assert.Contains(t, recorder.Header().Values("Vary"), "Origin")
+
+	recorder = serve(http.MethodGet, "/items/1", map[string]string{"Origin": "https://api.example.org"})
+	assert.Equal(t, "https://api.example.org", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = serve(http.MethodGet, "/items/1", map[string]string{"Origin": "https://evil.example.com"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	// Preflight lists the methods registered for the path
+	recorder = serve(http.MethodOptions, "/items/1", map[string]string{
+		"Origin":                         "https://app.example.com",
+		"Access-Control-Request-Method":  http.MethodPost,
+		"Access-Control-Request-Headers": "X-Custom",
+	})
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://app.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, HEAD, POST", recorder.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "X-Custom", recorder.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", recorder.Header().Get("Access-Control-Max-Age"))
+
+	// Preflight for disallowed origins and methods does not grant access
+	recorder = serve(http.MethodOptions, "/items/1", map[string]string{
+		"Origin":                        "https://evil.example.com",
+		"Access-Control-Request-Method": http.MethodPost,
+	})
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = serve(http.MethodOptions, "/items/1", map[string]string{
+		"Origin":                        "https://app.example.com",
+		"Access-Control-Request-Method": http.MethodDelete,
+	})
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	// Groups and routes override the API configuration
+	recorder = serve(http.MethodGet, "/admin/items", map[string]string{"Origin": "https://app.example.com"})
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = serve(http.MethodGet, "/admin/public", map[string]string{"Origin": "https://other.example.net"})
+	assert.Equal(t, "*", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	// The preflight is documented in the spec
+	recorder = serve(http.MethodGet, "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	paths := spec["paths"].(map[string]any)
+	options, ok := paths["/items/{id}"].(map[string]any)["options"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "CORS preflight", options["summary"])
+	assert.NotContains(t, paths["/admin/items"], "options")
+}
@@ -1,42 +1,330 @@
 package zorya
 
 import (
+	"crypto/sha256"
+	"embed"
+	"encoding/json"
 	"fmt"
 	"html"
+	"io/fs"
+	"log"
+	"maps"
+	"mime"
 	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 )
 
-// registerDocsEndpoint registers the docs endpoint if configured.
+//go:generate go run ./internal/cmd/docsassets -dir docsui
+
+// embeddedDocsAssets holds the docs UI bundles fetched by go generate, along
+// with the manifest pinning their versions.
+//
+//go:embed docsui
+var embeddedDocsAssets embed.FS
+
+// docsAssets is the file system the docs UI assets are served from.
+var docsAssets fs.FS = embeddedDocsAssets
+
+// docsCDNWarnings records the renderers whose missing assets have been
+// reported, so that the warning is logged once per process.
+var docsCDNWarnings sync.Map
+
+const (
+	docsAssetsDir      = "docsui"
+	docsAssetsManifest = "assets.json"
+	docsAssetsCDN      = "https://unpkg.com/"
+)
+
+// DocsRenderer selects the UI rendering the OpenAPI spec at Config.DocsPath.
+type DocsRenderer string
+
+const (
+	// DocsRendererStoplight renders the docs with Stoplight Elements (default).
+	DocsRendererStoplight DocsRenderer = "stoplight"
+
+	// DocsRendererSwaggerUI renders the docs with Swagger UI.
+	DocsRendererSwaggerUI DocsRenderer = "swagger-ui"
+
+	// DocsRendererRedoc renders the docs with Redoc.
+	DocsRendererRedoc DocsRenderer = "redoc"
+
+	// DocsRendererScalar renders the docs with Scalar API Reference.
+	DocsRendererScalar DocsRenderer = "scalar"
+)
+
+// docsRenderers lists the supported docs renderers.
+var docsRenderers = []DocsRenderer{DocsRendererStoplight, DocsRendererSwaggerUI, DocsRendererRedoc, DocsRendererScalar}
+
+// DocsUI configures the docs UI served at Config.DocsPath. The renderer
+// assets fetched with go generate are embedded in the binary and served under
+// DocsPath, so the docs work without access to a CDN. Assets that are not
+// embedded are loaded from their pinned version on unpkg.com.
+//
+// Example:
+//
+//	api := zorya.NewAPI(adapter, zorya.WithDocsUI(&zorya.DocsUI{
+//		Renderer: zorya.DocsRendererScalar,
+//		LogoURL:  "/static/logo.svg",
+//		Options: map[string]any{
+//			"theme": "purple",
+//			"authentication": map[string]any{"preferredSecurityScheme": "bearer"},
+//		},
+//	}))
+type DocsUI struct {
+	// Renderer selects the UI. Defaults to DocsRendererStoplight.
+	Renderer DocsRenderer
+
+	// Title is the page title. Defaults to the OpenAPI info title.
+	Title string
+
+	// LogoURL is the URL of a logo shown above the docs.
+	LogoURL string
+
+	// CSS is added to the page in a style element, e.g. to adjust colors and
+	// fonts to your brand.
+	CSS string
+
+	// HideTryItOut disables sending requests from the docs. Redoc never sends
+	// requests.
+	HideTryItOut bool
+
+	// Options are passed to the renderer as is and take precedence over the
+	// settings derived from the fields above: attributes of the elements-api
+	// component for Stoplight Elements, and the configuration object for
+	// Swagger UI, Redoc and Scalar (e.g. "persistAuthorization" for Swagger UI,
+	// "theme" for Redoc and Scalar, "authentication" for Scalar).
+	Options map[string]any
+}
+
+// docsRendererAssets describes the npm package providing a renderer's assets,
+// as listed in the docsui/assets.json manifest.
+type docsRendererAssets struct {
+	Package string   `json:"package"`
+	Version string   `json:"version"`
+	Files   []string `json:"files"`
+}
+
+// registerDocsEndpoint registers the docs endpoint and its assets if configured.
+// If the docs UI is misconfigured, the error is logged and the docs endpoint
+// responds with it.
 func registerDocsEndpoint(a *api) {
 	if a.config.DocsPath == "" {
 		return
 	}
 
-	title := "API Documentation"
-	if a.openAPI != nil && a.openAPI.Info != nil && a.openAPI.Info.Title != "" {
-		title = a.openAPI.Info.Title
-	}
-
-	openAPIPath := a.config.OpenAPIPath
-	if openAPIPath == "" {
-		openAPIPath = "/openapi.json"
+	htmlContent, err := docsPage(a)
+	if err != nil {
+		err = fmt.Errorf("docs UI unavailable: %w", err)
+		log.Printf("zorya: %v", err)
 	}
-	htmlContent := generateDocsHTML(openAPIPath, title)
 
 	a.adapter.Handle(&BaseRoute{
 		Method: http.MethodGet,
 		Path:   a.config.DocsPath,
 	}, func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			WriteErr(a, r, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(htmlContent))
 	})
 }
 
-// generateDocsHTML generates an HTML page with embedded Stoplight Elements.
-func generateDocsHTML(openAPIPath string, title string) string {
-	escapedTitle := html.EscapeString(title)
-	escapedPath := html.EscapeString(openAPIPath)
+// docsPage registers the assets of the configured renderer and returns the
+// HTML page of the docs UI.
+func docsPage(a *api) (string, error) {
+	if a.docsErr != nil {
+		return "", a.docsErr
+	}
+
+	ui := a.docsUI
+	if ui == nil {
+		ui = &DocsUI{}
+	}
+	renderer := ui.Renderer
+	if renderer == "" {
+		renderer = DocsRendererStoplight
+	}
+
+	manifest, err := readDocsManifest()
+	if err != nil {
+		return "", fmt.Errorf("failed to read docs assets manifest: %w", err)
+	}
+	rendererAssets, ok := manifest[renderer]
+	if !ok {
+		return "", fmt.Errorf("docs renderer %q is missing from the assets manifest", renderer)
+	}
+
+	title := ui.Title
+	if title == "" {
+		title = "API Documentation"
+		if a.openAPI != nil && a.openAPI.Info != nil && a.openAPI.Info.Title != "" {
+			title = a.openAPI.Info.Title
+		}
+	}
+
+	openAPIPath := a.config.OpenAPIPath
+	if openAPIPath == "" {
+		openAPIPath = "/openapi.json"
+	}
+
+	assetURLs := registerDocsAssets(a, renderer, rendererAssets)
+
+	return generateDocsHTML(renderer, ui, openAPIPath, title, assetURLs), nil
+}
+
+// readDocsManifest reads the renderer assets manifest.
+func readDocsManifest() (map[DocsRenderer]docsRendererAssets, error) {
+	data, err := fs.ReadFile(docsAssets, path.Join(docsAssetsDir, docsAssetsManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest map[DocsRenderer]docsRendererAssets
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// registerDocsAssets registers a route for each embedded asset of the renderer
+// under {DocsPath}/assets/{renderer}@{version}/ and returns the URLs of the
+// assets by file name. Assets missing from the binary are loaded from the
+// pinned version on the CDN.
+func registerDocsAssets(a *api, renderer DocsRenderer, assets docsRendererAssets) map[string]string {
+	urls := make(map[string]string, len(assets.Files))
+	for _, file := range assets.Files {
+		name := path.Base(file)
+
+		data, err := fs.ReadFile(docsAssets, path.Join(docsAssetsDir, string(renderer), name))
+		if err != nil {
+			if _, warned := docsCDNWarnings.LoadOrStore(renderer, true); !warned {
+				log.Printf("zorya: assets of docs renderer %q are not embedded, loading them from %s (run go generate to embed them)", renderer, docsAssetsCDN)
+			}
+			urls[name] = docsAssetsCDN + assets.Package + "@" + assets.Version + "/" + file
+
+			continue
+		}
+
+		url := fmt.Sprintf("%s/assets/%s@%s/%s", a.config.DocsPath, renderer, assets.Version, name)
+		urls[name] = url
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+
+		a.adapter.Handle(&BaseRoute{
+			Method: http.MethodGet,
+			Path:   url,
+		}, func(w http.ResponseWriter, r *http.Request) {
+			// The version is part of the URL, so the content never changes
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			w.Header().Set("ETag", etag)
+
+			if match := r.Header.Get("If-None-Match"); match == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(data)
+		})
+	}
+
+	return urls
+}
+
+// generateDocsHTML generates the HTML page rendering the spec with the
+// renderer, loading its assets from the given URLs.
+func generateDocsHTML(renderer DocsRenderer, ui *DocsUI, openAPIPath, title string, assetURLs map[string]string) string {
+	var head, body strings.Builder
+	if ui.CSS != "" {
+		fmt.Fprintf(&head, "\t<style>%s</style>\n", strings.ReplaceAll(ui.CSS, "</", `<\/`))
+	}
+	logo := ""
+	if ui.LogoURL != "" {
+		logo = fmt.Sprintf("\t<header class=\"docs-logo\"><img src=\"%s\" alt=\"%s\"></header>\n",
+			html.EscapeString(ui.LogoURL), html.EscapeString(title))
+	}
+
+	switch renderer {
+	case DocsRendererSwaggerUI:
+		config := map[string]any{"url": openAPIPath, "deepLinking": true}
+		if ui.HideTryItOut {
+			config["supportedSubmitMethods"] = []string{}
+		}
+		maps.Copy(config, ui.Options)
+
+		fmt.Fprintf(&head, "\t<link rel=\"stylesheet\" href=\"%s\">\n", html.EscapeString(assetURLs["swagger-ui.css"]))
+		body.WriteString(logo)
+		fmt.Fprintf(&body, `	<div id="swagger-ui"></div>
+	<script src="%s"></script>
+	<script>
+		const config = %s;
+		config.dom_id = "#swagger-ui";
+		config.presets = [SwaggerUIBundle.presets.apis];
+		window.ui = SwaggerUIBundle(config);
+	</script>
+`, html.EscapeString(assetURLs["swagger-ui-bundle.js"]), docsJSON(config))
+
+	case DocsRendererRedoc:
+		config := map[string]any{}
+		maps.Copy(config, ui.Options)
+
+		body.WriteString(logo)
+		fmt.Fprintf(&body, `	<div id="redoc"></div>
+	<script src="%s"></script>
+	<script>
+		Redoc.init(%s, %s, document.getElementById("redoc"));
+	</script>
+`, html.EscapeString(assetURLs["redoc.standalone.js"]), docsJSON(openAPIPath), docsJSON(config))
+
+	case DocsRendererScalar:
+		config := map[string]any{}
+		if ui.HideTryItOut {
+			config["hideTestRequestButton"] = true
+		}
+		maps.Copy(config, ui.Options)
+
+		body.WriteString(logo)
+		fmt.Fprintf(&body, `	<script id="api-reference" data-url="%s" data-configuration="%s"></script>
+	<script src="%s"></script>
+`, html.EscapeString(openAPIPath), html.EscapeString(docsJSON(config)), html.EscapeString(assetURLs["standalone.js"]))
+
+	default:
+		attrs := map[string]any{
+			"apiDescriptionUrl": openAPIPath,
+			"router":            "hash",
+			"layout":            "sidebar",
+		}
+		if ui.HideTryItOut {
+			attrs["hideTryIt"] = true
+		}
+		if ui.LogoURL != "" {
+			attrs["logo"] = ui.LogoURL
+		}
+		maps.Copy(attrs, ui.Options)
+
+		fmt.Fprintf(&head, "\t<link rel=\"stylesheet\" href=\"%s\">\n", html.EscapeString(assetURLs["styles.min.css"]))
+		body.WriteString("\t<elements-api")
+		for _, name := range slices.Sorted(maps.Keys(attrs)) {
+			fmt.Fprintf(&body, "\n\t\t%s=\"%s\"", html.EscapeString(name), html.EscapeString(docsAttr(attrs[name])))
+		}
+		fmt.Fprintf(&body, `
+	/>
+	<script src="%s"></script>
+`, html.EscapeString(assetURLs["web-components.min.js"]))
+	}
 
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html lang="en">
@@ -44,15 +332,29 @@ func generateDocsHTML(openAPIPath string, title string) string {
 	<meta charset="UTF-8">
 	<meta name="viewport" content="width=device-width, initial-scale=1.0">
 	<title>%s</title>
-	<link rel="stylesheet" href="https://unpkg.com/@stoplight/elements/styles.min.css">
-</head>
+%s</head>
 <body>
-	<elements-api
-		apiDescriptionUrl="%s"
-		router="hash"
-		layout="sidebar"
-	/>
-	<script src="https://unpkg.com/@stoplight/elements/web-components.min.js"></script>
-</body>
-</html>`, escapedTitle, escapedPath)
+%s</body>
+</html>`, html.EscapeString(title), head.String(), body.String())
+}
+
+// docsJSON encodes v for use in a script. Characters that could end the
+// script element are escaped by the encoder.
+func docsJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "null"
+	}
+
+	return string(data)
+}
+
+// docsAttr formats v as an HTML attribute value: strings as is, other values
+// as JSON.
+func docsAttr(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return docsJSON(v)
 }
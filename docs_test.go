@@ -0,0 +1,184 @@
+package zorya
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withDocsAssets embeds placeholder bundles for every renderer in the docs
+// assets manifest for the duration of the test.
+func withDocsAssets(t *testing.T) {
+	t.Helper()
+
+	manifest, err := readDocsManifest()
+	require.NoError(t, err)
+	manifestData, err := fs.ReadFile(docsAssets, "docsui/assets.json")
+	require.NoError(t, err)
+
+	original := docsAssets
+	t.Cleanup(func() { docsAssets = original })
+
+	assets := fstest.MapFS{"docsui/assets.json": {Data: manifestData}}
+	for renderer, rendererAssets := range manifest {
+		for _, file := range rendererAssets.Files {
+			assets["docsui/"+string(renderer)+"/"+path.Base(file)] = &fstest.MapFile{Data: []byte("/* " + file + " */")}
+		}
+	}
+	docsAssets = assets
+}
+
+func TestDocsUI_Renderers(t *testing.T) {
+	tests := []struct {
+		name     string
+		ui       *DocsUI
+		contains []string
+	}{
+		{
+			name: "stoplight",
+			ui:   &DocsUI{HideTryItOut: true, LogoURL: "/logo.svg", Options: map[string]any{"layout": "stacked"}},
+			contains: []string{
+				"<elements-api", `apiDescriptionUrl="/openapi.json"`, `hideTryIt="true"`, `logo="/logo.svg"`,
+				`layout="stacked"`, "/docs/assets/stoplight@",
+			},
+		},
+		{
+			name: "swagger-ui",
+			ui:   &DocsUI{Renderer: DocsRendererSwaggerUI, HideTryItOut: true, Options: map[string]any{"persistAuthorization": true}},
+			contains: []string{
+				"SwaggerUIBundle(config)", `"url":"/openapi.json"`, `"supportedSubmitMethods":[]`,
+				`"persistAuthorization":true`, "/docs/assets/swagger-ui@",
+			},
+		},
+		{
+			name:     "redoc",
+			ui:       &DocsUI{Renderer: DocsRendererRedoc, LogoURL: "/logo.svg", Options: map[string]any{"hideDownloadButton": true}},
+			contains: []string{`Redoc.init("/openapi.json", {"hideDownloadButton":true}`, `<img src="/logo.svg"`, "/docs/assets/redoc@"},
+		},
+		{
+			name: "scalar",
+			ui:   &DocsUI{Renderer: DocsRendererScalar, HideTryItOut: true, Options: map[string]any{"theme": "purple"}},
+			contains: []string{
+				`id="api-reference"`, `data-url="/openapi.json"`, "&#34;hideTestRequestButton&#34;:true",
+				"&#34;theme&#34;:&#34;purple&#34;", "/docs/assets/scalar@",
+			},
+		},
+	}
+
+	withDocsAssets(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewMux()
+			NewAPI(&testChiAdapter{router: router}, WithDocsUI(tt.ui))
+
+			req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			for _, s := range tt.contains {
+				assert.Contains(t, recorder.Body.String(), s)
+			}
+		})
+	}
+}
+
+func TestDocsUI_TitleAndCSS(t *testing.T) {
+	withDocsAssets(t)
+
+	router := chi.NewMux()
+	NewAPI(&testChiAdapter{router: router}, WithDocsUI(&DocsUI{
+		Title: "Pets <API>",
+		CSS:   "body { color: red; } </style><script>",
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	htmlBody := recorder.Body.String()
+	assert.Contains(t, htmlBody, "<title>Pets &lt;API&gt;</title>")
+	assert.Contains(t, htmlBody, "body { color: red; }")
+	assert.NotContains(t, htmlBody, "</style><script>", "CSS must not close the style element")
+}
+
+func TestDocsUI_UnknownRenderer(t *testing.T) {
+	withDocsAssets(t)
+
+	router := chi.NewMux()
+	NewAPI(&testChiAdapter{router: router}, WithDocsUI(&DocsUI{Renderer: "rapidoc"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `unknown docs renderer \"rapidoc\"`)
+}
+
+func TestDocsUI_MissingAssets(t *testing.T) {
+	original := docsAssets
+	t.Cleanup(func() { docsAssets = original })
+	docsAssets = fstest.MapFS{
+		"docsui/assets.json": {Data: []byte(`{"redoc": {"package": "redoc", "version": "2.1.5", "files": ["bundles/redoc.standalone.js"]}}`)},
+	}
+
+	// Bundles that are not embedded are loaded from their pinned version on the CDN
+	router := chi.NewMux()
+	NewAPI(&testChiAdapter{router: router}, WithDocsUI(&DocsUI{Renderer: DocsRendererRedoc}))
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `<script src="https://unpkg.com/redoc@2.1.5/bundles/redoc.standalone.js">`)
+}
+
+func TestDocsUI_EmbeddedAssets(t *testing.T) {
+	original := docsAssets
+	t.Cleanup(func() { docsAssets = original })
+	docsAssets = fstest.MapFS{
+		"docsui/assets.json":               {Data: []byte(`{"redoc": {"package": "redoc", "version": "2.1.5", "files": ["bundles/redoc.standalone.js"]}}`)},
+		"docsui/redoc/redoc.standalone.js": {Data: []byte("window.Redoc = {};")},
+	}
+
+	router := chi.NewMux()
+	NewAPI(&testChiAdapter{router: router}, WithDocsUI(&DocsUI{Renderer: DocsRendererRedoc}))
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assetPath := "/docs/assets/redoc@2.1.5/redoc.standalone.js"
+	assert.Contains(t, recorder.Body.String(), `<script src="`+assetPath+`">`)
+	assert.NotContains(t, recorder.Body.String(), "unpkg.com", "embedded assets must not be loaded from the CDN")
+
+	req = httptest.NewRequest(http.MethodGet, assetPath, nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "window.Redoc = {};", recorder.Body.String())
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "javascript")
+	assert.Contains(t, recorder.Header().Get("Cache-Control"), "immutable")
+	etag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req = httptest.NewRequest(http.MethodGet, assetPath, nil)
+	req.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
@@ -1,7 +1,7 @@
 // auth-jwt demonstrates declarative role-based security using Zorya's Secure() helper.
-// A simple bearer-token middleware reads the token from the Authorization header,
-// looks up the caller's roles, and Zorya's GetRouteSecurityContext is used to enforce
-// the required roles on each route.
+// A simple bearer-token Authorizer reads the token from the Authorization header,
+// looks up the caller's roles, and Zorya enforces the required roles on each route
+// before the handler runs.
 //
 // Run:
 //
@@ -40,53 +40,38 @@ var tokenRoles = map[string][]string{
 	"bob-token":   {"viewer", "admin"},
 }
 
-// --- Auth middleware ---
-
-func authMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Resolve security requirements for this route (nil = public)
-		sec := zorya.GetRouteSecurityContext(r)
-		if sec == nil {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Extract bearer token
-		authHeader := r.Header.Get("Authorization")
-		token, ok := strings.CutPrefix(authHeader, "Bearer ")
-		if !ok || token == "" {
-			http.Error(w, `{"title":"Unauthorized","status":401}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Look up caller roles
-		callerRoles, known := tokenRoles[token]
-		if !known {
-			http.Error(w, `{"title":"Unauthorized","status":401}`, http.StatusUnauthorized)
-			return
-		}
-
-		// Check required roles (any match grants access)
-		if !hasAnyRole(callerRoles, sec.Roles) {
-			http.Error(w, `{"title":"Forbidden","status":403}`, http.StatusForbidden)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+// --- Authorizer ---
+
+type caller struct {
+	Token string
+	Roles []string
 }
 
-func hasAnyRole(caller, required []string) bool {
-	set := make(map[string]struct{}, len(caller))
-	for _, r := range caller {
-		set[r] = struct{}{}
+type tokenAuthorizer struct{}
+
+// Authenticate extracts the caller from the bearer token (nil = anonymous, 401).
+func (tokenAuthorizer) Authenticate(r *http.Request) (any, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, nil
 	}
-	for _, r := range required {
-		if _, ok := set[r]; ok {
-			return true
-		}
+
+	roles, known := tokenRoles[token]
+	if !known {
+		return nil, zorya.Error401Unauthorized("unknown token")
 	}
-	return false
+
+	return &caller{Token: token, Roles: roles}, nil
+}
+
+// Authorize checks required roles (any match grants access).
+func (tokenAuthorizer) Authorize(r *http.Request, principal any, sec *zorya.RouteSecurityContext) error {
+	c, _ := principal.(*caller)
+	if !zorya.HasRequiredRoles(c.Roles, sec.Roles) {
+		return zorya.Error403Forbidden("missing required role")
+	}
+
+	return nil
 }
 
 // --- Input / output types ---
@@ -136,11 +121,9 @@ func main() {
 	api := zorya.NewAPI(
 		adapters.NewChi(router),
 		zorya.WithConfig(zorya.DefaultConfig()),
+		zorya.WithAuthorizer(tokenAuthorizer{}),
 	)
 
-	// Register the auth middleware globally
-	api.UseMiddleware(authMiddleware)
-
 	// Public route
 	zorya.Get(api, "/status", getStatus)
 
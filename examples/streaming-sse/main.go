@@ -1,6 +1,7 @@
-// streaming-sse demonstrates Server-Sent Events (SSE) using Zorya's streaming
-// body pattern. The handler returns a function body that writes events directly
-// to the response writer, checking context cancellation for clean shutdown.
+// streaming-sse demonstrates Server-Sent Events (SSE) using Zorya's EventStream
+// body. The handler sends typed events; Zorya writes the text/event-stream
+// framing, sends heartbeats and stops the stream when the client disconnects.
+// Reconnecting clients resume after the last event they received.
 //
 // Run:
 //
@@ -10,6 +11,9 @@
 //
 //	curl -N http://localhost:8080/events
 //
+//	# Resume after event 4
+//	curl -N -H "Last-Event-ID: 4" http://localhost:8080/events
+//
 //	# Or in a browser: open http://localhost:8080/
 package main
 
@@ -18,6 +22,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -27,49 +32,56 @@ import (
 
 // --- Input / output types ---
 
+type EventStreamInput struct {
+	zorya.EventStreamParams
+}
+
 type EventStreamOutput struct {
-	Body func(w http.ResponseWriter) error
+	Body zorya.EventStream
 }
 
+// --- Event types ---
+
+type Tick struct {
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+}
+
+type Done struct{}
+
 // --- Handler ---
 
-func streamEvents(ctx context.Context, _ *struct{}) (*EventStreamOutput, error) {
-	out := &EventStreamOutput{}
-	out.Body = func(w http.ResponseWriter) error {
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.WriteHeader(http.StatusOK)
-
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			return fmt.Errorf("streaming not supported by this server")
+func streamEvents(_ context.Context, input *EventStreamInput) (*EventStreamOutput, error) {
+	start := 0
+	if input.LastEventID != "" {
+		last, err := strconv.Atoi(input.LastEventID)
+		if err != nil {
+			return nil, zorya.Error400BadRequest("invalid Last-Event-ID")
 		}
+		start = last + 1
+	}
 
+	out := &EventStreamOutput{}
+	out.Body = func(ctx context.Context, send zorya.EventSender) error {
 		ticker := time.NewTicker(time.Second)
 		defer ticker.Stop()
 
-		for i := range 10 {
+		for i := start; i < 10; i++ {
 			select {
 			case <-ctx.Done():
 				// Client disconnected
 				return nil
 			case t := <-ticker.C:
-				event := fmt.Sprintf(`{"seq":%d,"time":"%s"}`, i, t.Format(time.RFC3339))
-				if _, err := fmt.Fprintf(w, "data: %s\n\n", event); err != nil {
+				if err := send(zorya.Event{ID: strconv.Itoa(i), Data: Tick{Seq: i, Time: t}}); err != nil {
 					return err
 				}
-				flusher.Flush()
 			}
 		}
 
 		// Signal end-of-stream
-		if _, err := fmt.Fprintf(w, "event: done\ndata: {}\n\n"); err != nil {
-			return err
-		}
-		flusher.Flush()
-		return nil
+		return send.Data(Done{})
 	}
+
 	return out, nil
 }
 
@@ -88,11 +100,11 @@ func landingPage(_ context.Context, _ *struct{}) (*PageOutput, error) {
 <ul id="log"></ul>
 <script>
 const es = new EventSource("/events");
-es.onmessage = e => {
+es.addEventListener("tick", e => {
     const li = document.createElement("li");
     li.textContent = e.data;
     document.getElementById("log").appendChild(li);
-};
+});
 es.addEventListener("done", () => es.close());
 </script></body></html>`); err != nil {
 			return err
@@ -112,7 +124,10 @@ func main() {
 	}))
 
 	zorya.Get(api, "/", landingPage)
-	zorya.Get(api, "/events", streamEvents)
+	zorya.Get(api, "/events", streamEvents, zorya.Events(map[string]any{
+		"tick": Tick{},
+		"done": Done{},
+	}))
 
 	log.Println("Listening on :8080  —  open http://localhost:8080 in a browser")
 	log.Fatal(http.ListenAndServe(":8080", router))
@@ -1,15 +1,24 @@
 package zorya
 
 import (
+	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"io"
+	"reflect"
 
 	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
 )
 
-// Format defines how to marshal values for a given content type (e.g., application/json).
+// Format defines how to marshal and unmarshal values for a given content type
+// (e.g., application/json). Formats without Unmarshal are only used for
+// responses; requests sent with their content type are rejected with 415
+// Unsupported Media Type.
 type Format struct {
-	Marshal func(w io.Writer, v any) error
+	Marshal   func(w io.Writer, v any) error
+	Unmarshal func(data []byte, v any) error
 }
 
 // JSONFormat returns a Format for application/json.
@@ -21,6 +30,7 @@ func JSONFormat() Format {
 
 			return enc.Encode(v)
 		},
+		Unmarshal: json.Unmarshal,
 	}
 }
 
@@ -33,10 +43,18 @@ func CBORFormat() Format {
 		panic("zorya: CBOR enc mode setup failed: " + err.Error())
 	}
 
+	decMode, err := cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]any(nil)),
+	}.DecMode()
+	if err != nil {
+		panic("zorya: CBOR dec mode setup failed: " + err.Error())
+	}
+
 	return Format{
 		Marshal: func(w io.Writer, v any) error {
 			return encMode.NewEncoder(w).Encode(v)
 		},
+		Unmarshal: decMode.Unmarshal,
 	}
 }
 
@@ -52,3 +70,97 @@ func DefaultFormats() map[string]Format {
 		"cbor":             cborFmt, // For +cbor suffix matching
 	}
 }
+
+// XMLFormat returns a Format for application/xml using encoding/xml, so xml
+// struct tags apply. It is not part of DefaultFormats; register it together
+// with the "xml" key to match +xml suffixes:
+//
+//	zorya.WithFormats(map[string]zorya.Format{
+//		"application/xml": zorya.XMLFormat(),
+//		"xml":             zorya.XMLFormat(), // For +xml suffix matching
+//	})
+func XMLFormat() Format {
+	return Format{
+		Marshal: func(w io.Writer, v any) error {
+			return xml.NewEncoder(w).Encode(v)
+		},
+		Unmarshal: xml.Unmarshal,
+	}
+}
+
+// YAMLFormat returns a Format for application/yaml. Values are converted
+// through JSON, so json struct tags and json.Marshaler implementations apply
+// and struct field order is preserved. It is not part of DefaultFormats;
+// register it together with the "yaml" key to match +yaml suffixes.
+func YAMLFormat() Format {
+	return Format{
+		Marshal: func(w io.Writer, v any) error {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			return writeYAML(w, data)
+		},
+		Unmarshal: func(data []byte, v any) error {
+			var value any
+			if err := yaml.Unmarshal(data, &value); err != nil {
+				return err
+			}
+
+			jsonData, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+
+			return json.Unmarshal(jsonData, v)
+		},
+	}
+}
+
+// MsgPackFormat returns a Format for application/msgpack. Struct fields are
+// named after their json tags. It is not part of DefaultFormats.
+func MsgPackFormat() Format {
+	return Format{
+		Marshal: func(w io.Writer, v any) error {
+			enc := msgpack.NewEncoder(w)
+			enc.SetCustomStructTag("json")
+
+			return enc.Encode(v)
+		},
+		Unmarshal: func(data []byte, v any) error {
+			dec := msgpack.NewDecoder(bytes.NewReader(data))
+			dec.SetCustomStructTag("json")
+
+			return dec.Decode(v)
+		},
+	}
+}
+
+// writeYAML writes a JSON document as block-style YAML, keeping the order of
+// object keys.
+func writeYAML(w io.Writer, jsonData []byte) error {
+	// JSON is valid YAML, decoding it into a node keeps key order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonData, &node); err != nil {
+		return err
+	}
+	clearYAMLStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// clearYAMLStyle resets the flow and quoting styles inherited from JSON so
+// that nodes are emitted in block style, quoting strings only when needed.
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
@@ -11,18 +11,11 @@ import "net/http"
 type Group struct {
 	API
 	prefixes     []string
-	adapter      Adapter
 	modifiers    []func(o *BaseRoute, next func(*BaseRoute))
 	middlewares  Middlewares
 	transformers []Transformer
 	security     *RouteSecurity
-}
-
-// groupAdapter is an Adapter wrapper that registers multiple operation handlers
-// with the underlying adapter based on the group's prefixes.
-type groupAdapter struct {
-	Adapter
-	group *Group
+	cors         *CORS
 }
 
 // NewGroup creates a new group of routes with the given prefixes, if any. A
@@ -37,7 +30,6 @@ type groupAdapter struct {
 //	})
 func NewGroup(api API, prefixes ...string) *Group {
 	group := &Group{API: api, prefixes: prefixes}
-	group.adapter = &groupAdapter{Adapter: api.Adapter(), group: group}
 	if len(prefixes) > 0 {
 		group.UseModifier(PrefixModifier(prefixes))
 	}
@@ -45,15 +37,15 @@ func NewGroup(api API, prefixes ...string) *Group {
 	return group
 }
 
-// Adapter returns the group's adapter, which applies group modifiers before delegating to the underlying API adapter.
-func (g *Group) Adapter() Adapter {
-	return g.adapter
-}
-
-func (a *groupAdapter) Handle(route *BaseRoute, handler http.HandlerFunc) {
-	a.group.ModifyOperation(route, func(route *BaseRoute) {
-		a.Adapter.Handle(route, handler)
+// resolveRoutes runs the group's operation modifiers on the route and returns
+// the routes resolved by the parent API for each resulting route.
+func (g *Group) resolveRoutes(route *BaseRoute) []*BaseRoute {
+	var routes []*BaseRoute
+	g.ModifyOperation(route, func(route *BaseRoute) {
+		routes = append(routes, g.API.resolveRoutes(route)...)
 	})
+
+	return routes
 }
 
 // ModifyOperation runs all operation modifiers in the group on the given
@@ -61,6 +53,9 @@ func (a *groupAdapter) Handle(route *BaseRoute, handler http.HandlerFunc) {
 // before it is registered with the router.
 func (g *Group) ModifyOperation(route *BaseRoute, next func(*BaseRoute)) {
 	g.mergeSecurity(route)
+	if route.CORS == nil {
+		route.CORS = g.cors
+	}
 
 	chain := func(route *BaseRoute) {
 		// Call the final handler.
@@ -94,13 +89,7 @@ func (g *Group) mergeSecurity(route *BaseRoute) {
 
 // copySecurity creates a deep copy of the group's security configuration.
 func (g *Group) copySecurity() *RouteSecurity {
-	return &RouteSecurity{
-		Roles:            append([]string(nil), g.security.Roles...),
-		Permissions:      append([]string(nil), g.security.Permissions...),
-		Resource:         g.security.Resource,
-		Action:           g.security.Action,
-		ResourceResolver: g.security.ResourceResolver,
-	}
+	return g.security.clone()
 }
 
 // mergeSecurityFields merges group security fields into route security.
@@ -120,6 +109,9 @@ func (g *Group) mergeSecurityFields(routeSec *RouteSecurity) {
 	if routeSec.ResourceResolver == nil && g.security.ResourceResolver != nil {
 		routeSec.ResourceResolver = g.security.ResourceResolver
 	}
+	if len(routeSec.Requirements) == 0 && len(g.security.Requirements) > 0 {
+		routeSec.Requirements = append([]SecurityRequirement(nil), g.security.Requirements...)
+	}
 }
 
 // UseModifier adds an operation modifier function to the group that will be run
@@ -165,6 +157,12 @@ func (g *Group) WithSecurity(security *RouteSecurity) {
 	g.security = security
 }
 
+// UseCORS sets the CORS configuration for all routes in the group that do not
+// set their own, overriding the API's (see WithCORS).
+func (g *Group) UseCORS(cors *CORS) {
+	g.cors = cors
+}
+
 // UseRoles requires users to have at least one of the specified roles for all routes in the group.
 func (g *Group) UseRoles(roles ...string) {
 	if g.security == nil {
@@ -189,6 +187,16 @@ func (g *Group) UseResource(resource string) {
 	g.security.Resource = resource
 }
 
+// UseSecuritySchemes adds a security requirement for all routes in the group
+// where all of the named schemes must be satisfied together. Call it multiple
+// times to declare alternatives. See SecuritySchemes.
+func (g *Group) UseSecuritySchemes(names ...string) {
+	if g.security == nil {
+		g.security = &RouteSecurity{}
+	}
+	SecuritySchemes(names...)(g.security)
+}
+
 // Transform runs all transformers in the group on the response, in the order
 // they were added, then chains to the parent API's transformers.
 func (g *Group) Transform(r *http.Request, status int, v any) (any, error) {
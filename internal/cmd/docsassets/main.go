@@ -0,0 +1,130 @@
+// Command docsassets downloads the docs UI renderer bundles listed in the
+// assets.json manifest from the npm registry, so they can be embedded into the
+// binary. It is run with go generate from the module root.
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const registryURL = "https://registry.npmjs.org/"
+
+// rendererAssets mirrors the manifest entries read by the zorya package.
+type rendererAssets struct {
+	Package string   `json:"package"`
+	Version string   `json:"version"`
+	Files   []string `json:"files"`
+}
+
+func main() {
+	dir := flag.String("dir", "docsui", "directory holding assets.json, where the bundles are written")
+	flag.Parse()
+
+	if err := run(*dir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "assets.json"))
+	if err != nil {
+		return err
+	}
+
+	var manifest map[string]rendererAssets
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	for renderer, assets := range manifest {
+		if err := fetch(client, filepath.Join(dir, renderer), assets); err != nil {
+			return fmt.Errorf("%s: %w", renderer, err)
+		}
+		log.Printf("%s: %s@%s", renderer, assets.Package, assets.Version)
+	}
+
+	return nil
+}
+
+// fetch downloads the package tarball and extracts the listed files to dir.
+func fetch(client *http.Client, dir string, assets rendererAssets) error {
+	// Scoped packages keep the scope in the path but not in the file name
+	tarball := fmt.Sprintf("%s%s/-/%s-%s.tgz", registryURL, assets.Package, path.Base(assets.Package), assets.Version)
+
+	resp, err := client.Get(tarball)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", tarball, resp.Status)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	missing := slices.Clone(assets.Files)
+	archive := tar.NewReader(gz)
+	for len(missing) > 0 {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// npm tarballs put the package contents in a package/ directory
+		name, ok := strings.CutPrefix(path.Clean(header.Name), "package/")
+		i := slices.Index(missing, name)
+		if !ok || header.Typeflag != tar.TypeReg || i < 0 {
+			continue
+		}
+
+		if err := writeFile(filepath.Join(dir, path.Base(missing[i])), archive); err != nil {
+			return err
+		}
+		missing = slices.Delete(missing, i, i+1)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("files not found in %s: %v", tarball, missing)
+	}
+
+	return nil
+}
+
+func writeFile(name string, r io.Reader) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
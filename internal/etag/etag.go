@@ -0,0 +1,145 @@
+// Package etag parses and compares entity tags (RFC 9110 8.8.3). It is shared
+// by zorya and the conditional package, which exports its types.
+package etag
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETag is an entity tag (RFC 9110 8.8.3).
+type ETag struct {
+	// Tag is the opaque tag, without the quotes.
+	Tag string
+
+	// Weak marks a weak validator, written with the W/ prefix.
+	Weak bool
+}
+
+// ErrInvalidETag is returned when an entity tag cannot be parsed.
+var ErrInvalidETag = errors.New("invalid entity tag")
+
+// Parse parses an entity tag such as "xyz" or W/"xyz".
+func Parse(s string) (ETag, error) {
+	etag, rest, err := scanETag(strings.TrimSpace(s))
+	if err != nil {
+		return ETag{}, err
+	}
+	if rest != "" {
+		return ETag{}, fmt.Errorf("%w: %q", ErrInvalidETag, s)
+	}
+
+	return etag, nil
+}
+
+// ParseList parses the values of an If-Match or If-None-Match header.
+// Each value may hold a comma-separated list of entity tags. It reports wildcard
+// as true if the list holds "*", which matches any current representation.
+func ParseList(values ...string) (etags []ETag, wildcard bool, err error) {
+	for _, value := range values {
+		rest := strings.TrimSpace(value)
+		for rest != "" {
+			if rest[0] == ',' {
+				rest = strings.TrimSpace(rest[1:])
+
+				continue
+			}
+			if rest[0] == '*' {
+				wildcard = true
+				rest = strings.TrimSpace(rest[1:])
+
+				continue
+			}
+
+			var etag ETag
+			if etag, rest, err = scanETag(rest); err != nil {
+				return nil, false, err
+			}
+			etags = append(etags, etag)
+			rest = strings.TrimSpace(rest)
+		}
+	}
+
+	return etags, wildcard, nil
+}
+
+// scanETag parses the entity tag at the start of s and returns the rest.
+func scanETag(s string) (ETag, string, error) {
+	var etag ETag
+	if strings.HasPrefix(s, "W/") {
+		etag.Weak = true
+		s = s[2:]
+	}
+
+	if len(s) < 2 || s[0] != '"' {
+		return ETag{}, "", fmt.Errorf("%w: %q", ErrInvalidETag, s)
+	}
+	end := strings.IndexByte(s[1:], '"')
+	if end < 0 {
+		return ETag{}, "", fmt.Errorf("%w: %q", ErrInvalidETag, s)
+	}
+	etag.Tag = s[1 : end+1]
+
+	return etag, s[end+2:], nil
+}
+
+// ParseCurrent parses the ETag of the current representation, as passed to
+// conditional checks. Unquoted values are taken as the tag of a strong ETag.
+// Reports false if there is none.
+func ParseCurrent(s string) (ETag, bool) {
+	if s == "" {
+		return ETag{}, false
+	}
+	if etag, err := Parse(s); err == nil {
+		return etag, true
+	}
+
+	return ETag{Tag: s}, true
+}
+
+// String returns the entity tag as written in headers.
+func (e ETag) String() string {
+	if e.Weak {
+		return `W/"` + e.Tag + `"`
+	}
+
+	return `"` + e.Tag + `"`
+}
+
+// StrongMatch reports whether both entity tags are strong and equal, as
+// required by If-Match and If-Range (RFC 9110 8.8.3.2).
+func (e ETag) StrongMatch(other ETag) bool {
+	return !e.Weak && !other.Weak && e.Tag == other.Tag
+}
+
+// WeakMatch reports whether the entity tags are equal regardless of whether
+// they are weak, as used by If-None-Match (RFC 9110 8.8.3.2).
+func (e ETag) WeakMatch(other ETag) bool {
+	return e.Tag == other.Tag
+}
+
+// IfRangeMatches reports whether a Range request should be served as partial
+// content: true if the If-Range header is empty, its entity tag strongly
+// matches the current ETag, or its date equals the current modification time.
+// Otherwise the full representation must be sent (RFC 9110 13.1.5).
+func IfRangeMatches(ifRange string, currentETag string, currentModified time.Time) bool {
+	if ifRange == "" {
+		return true
+	}
+
+	if etag, err := Parse(ifRange); err == nil {
+		current, exists := ParseCurrent(currentETag)
+
+		return exists && etag.StrongMatch(current)
+	}
+
+	date, err := http.ParseTime(ifRange)
+	if err != nil || currentModified.IsZero() {
+		return false
+	}
+
+	return currentModified.Truncate(time.Second).Equal(date)
+}
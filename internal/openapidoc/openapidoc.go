@@ -0,0 +1,94 @@
+// Package openapidoc reads generated OpenAPI documents decoded into maps. It
+// is shared by zorya and its test helpers.
+package openapidoc
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// Path item fields of QUERY and custom method operations. OpenAPI 3.1 path
+// items have no fields for them, so they are extensions named after the query
+// and additionalOperations fields of OpenAPI 3.2.
+const (
+	QueryField                = "x-query"
+	AdditionalOperationsField = "x-additionalOperations"
+)
+
+// pathItemFields are the methods with a fixed field in a path item, along with
+// that field, in the order of the OpenAPI specification.
+var pathItemFields = []struct{ method, field string }{
+	{http.MethodGet, "get"},
+	{http.MethodPut, "put"},
+	{http.MethodPost, "post"},
+	{http.MethodDelete, "delete"},
+	{http.MethodOptions, "options"},
+	{http.MethodHead, "head"},
+	{http.MethodPatch, "patch"},
+	{http.MethodTrace, "trace"},
+	{"QUERY", "query"},
+}
+
+// PathItemField returns the path item field holding the operation for the
+// method, and false for methods whose operations are entries of
+// AdditionalOperationsField. QUERY operations are held in QueryField.
+func PathItemField(method string) (string, bool) {
+	if method == "QUERY" {
+		return QueryField, true
+	}
+	for _, f := range pathItemFields {
+		if f.method == method {
+			return f.field, true
+		}
+	}
+
+	return "", false
+}
+
+// PathItemOperation returns the operation for the method in a path item, or
+// nil if there is none. QUERY and custom method operations are read from the
+// OpenAPI 3.2 fields as well as from their extensions.
+func PathItemOperation(pathItem map[string]any, method string) map[string]any {
+	for _, f := range pathItemFields {
+		if f.method != method {
+			continue
+		}
+		operation, ok := pathItem[f.field].(map[string]any)
+		if !ok && method == "QUERY" {
+			operation, _ = pathItem[QueryField].(map[string]any)
+		}
+
+		return operation
+	}
+
+	for _, field := range []string{"additionalOperations", AdditionalOperationsField} {
+		additional, _ := pathItem[field].(map[string]any)
+		if operation, ok := additional[method].(map[string]any); ok {
+			return operation
+		}
+	}
+
+	return nil
+}
+
+// Methods returns the methods of the operations in a path item: those with a
+// fixed field in the order of the OpenAPI specification, followed by custom
+// methods in sorted order.
+func Methods(pathItem map[string]any) []string {
+	var methods []string
+	for _, f := range pathItemFields {
+		if PathItemOperation(pathItem, f.method) != nil {
+			methods = append(methods, f.method)
+		}
+	}
+
+	var custom []string
+	for _, field := range []string{"additionalOperations", AdditionalOperationsField} {
+		additional, _ := pathItem[field].(map[string]any)
+		custom = append(custom, slices.Collect(maps.Keys(additional))...)
+	}
+	slices.Sort(custom)
+
+	return append(methods, slices.Compact(custom)...)
+}
@@ -0,0 +1,142 @@
+package openapidoc
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ResponseValidator checks responses against the operations of an OpenAPI
+// document. Response body schemas are compiled on first use and cached.
+type ResponseValidator struct {
+	doc map[string]any
+
+	mu      sync.Mutex
+	schemas map[string]*jsonschema.Schema // Compiled response schemas by operation, status and media type
+}
+
+// NewResponseValidator creates a validator for the document, which must not be
+// modified afterwards.
+func NewResponseValidator(doc map[string]any) *ResponseValidator {
+	return &ResponseValidator{doc: doc, schemas: make(map[string]*jsonschema.Schema)}
+}
+
+// UndocumentedStatusError is returned by ResponseValidator.Check for a status
+// that the operation does not document.
+type UndocumentedStatusError struct {
+	Status int
+}
+
+func (e *UndocumentedStatusError) Error() string {
+	return fmt.Sprintf("status %d is not documented", e.Status)
+}
+
+// Check checks a response of the operation for the method on the path template:
+// the status must be documented (see UndocumentedStatusError) unless it is 304
+// Not Modified, the media type
+// must be documented for that status, and JSON bodies must match the
+// documented schema. Bodies in other formats are not checked, and responses of
+// operations that are not in the document are not checked at all.
+func (v *ResponseValidator) Check(path, method string, status int, contentType string, body []byte) error {
+	paths, _ := v.doc["paths"].(map[string]any)
+	pathItem, _ := paths[path].(map[string]any)
+	operation := PathItemOperation(pathItem, method)
+	if operation == nil {
+		return nil
+	}
+
+	responses, _ := operation["responses"].(map[string]any)
+	key := responseKey(responses, status)
+	if key == "" {
+		// Conditional requests may be answered with 304 without documenting it
+		if status == http.StatusNotModified {
+			return nil
+		}
+
+		return &UndocumentedStatusError{Status: status}
+	}
+
+	response, _ := responses[key].(map[string]any)
+	content, _ := response["content"].(map[string]any)
+	if len(body) == 0 || len(content) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type %q: %w", contentType, err)
+	}
+	media, ok := content[mediaType].(map[string]any)
+	if !ok {
+		return fmt.Errorf("media type %s is not documented for status %d", mediaType, status)
+	}
+	object, ok := media["schema"].(map[string]any)
+	if !ok || !isJSON(mediaType) {
+		return nil
+	}
+
+	schema, err := v.compile(strings.Join([]string{method, path, key, mediaType}, " "), object)
+	if err != nil {
+		return err
+	}
+
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+
+	return schema.Validate(value)
+}
+
+// compile compiles a response schema of the document. The schema is compiled
+// as a document of its own, with the document components alongside so that
+// references to component schemas resolve.
+func (v *ResponseValidator) compile(key string, object map[string]any) (*jsonschema.Schema, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if schema, ok := v.schemas[key]; ok {
+		return schema, nil
+	}
+
+	resource := map[string]any{"components": v.doc["components"]}
+	maps.Copy(resource, object)
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("response.json", resource); err != nil {
+		return nil, fmt.Errorf("invalid response schema for %s: %w", key, err)
+	}
+	schema, err := compiler.Compile("response.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema for %s: %w", key, err)
+	}
+
+	v.schemas[key] = schema
+
+	return schema, nil
+}
+
+// responseKey returns the key of the response documented for status: the
+// exact code, its range (e.g. "4XX") or "default", or an empty string if there
+// is none.
+func responseKey(responses map[string]any, status int) string {
+	for _, key := range []string{strconv.Itoa(status), strconv.Itoa(status/100) + "XX", "default"} {
+		if _, ok := responses[key]; ok {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// isJSON reports whether the media type is JSON or has a +json suffix.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
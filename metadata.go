@@ -32,7 +32,8 @@ func conditionalSchemaDefault(field reflect.StructField, index int) any {
 }
 
 // FindBodyField finds the field with "body" tag in the struct metadata.
-// If none, falls back to a field named "Body" with type func(http.ResponseWriter) error (streaming).
+// If none, falls back to a field named "Body" with type func(http.ResponseWriter) error,
+// EventStream (streaming) or Content.
 // Returns nil if no body field is found.
 func FindBodyField(structMeta *schema.StructMetadata) *schema.FieldMetadata {
 	for i := range structMeta.Fields {
@@ -40,10 +41,10 @@ func FindBodyField(structMeta *schema.StructMetadata) *schema.FieldMetadata {
 			return &structMeta.Fields[i]
 		}
 	}
-	// Fallback: streaming body func(w http.ResponseWriter) error without body tag
+	// Fallback: streaming body func(w http.ResponseWriter) error, EventStream or Content without body tag
 	for i := range structMeta.Fields {
 		f := &structMeta.Fields[i]
-		if f.StructFieldName == "Body" && isStreamingBodyFunc(f.Type) {
+		if f.StructFieldName == "Body" && (isStreamingBodyFunc(f.Type) || f.Type == eventStreamType || f.Type == contentBodyType) {
 			return f
 		}
 	}
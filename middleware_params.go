@@ -3,11 +3,16 @@ package zorya
 import (
 	"context"
 	"net/http"
+	"slices"
+	"strings"
 )
 
 type paramsContextKey string
 
-const routerParamsKey paramsContextKey = "zorya.routerParams"
+const (
+	routerParamsKey paramsContextKey = "zorya.routerParams"
+	adapterPathKey  paramsContextKey = "zorya.adapterPath"
+)
 
 // GetRouterParams retrieves router parameters from the request context.
 // Returns nil if no parameters are available.
@@ -37,7 +42,7 @@ func newRouterParamsMiddleware(adapter Adapter, route *BaseRoute) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract params using adapter-specific logic
-			params := adapter.ExtractRouterParams(r, route)
+			params := extractRouterParams(adapter, r, route)
 
 			// Store in context for downstream use
 			ctx := context.WithValue(r.Context(), routerParamsKey, params)
@@ -45,3 +50,44 @@ func newRouterParamsMiddleware(adapter Adapter, route *BaseRoute) Middleware {
 		})
 	}
 }
+
+// extractRouterParams extracts the router parameters of the route. When the
+// request was routed by the adapter for a path of the route table with other
+// parameter names (see routeTable.lookup), the parameters are extracted with
+// those names and renamed to the route's by position.
+func extractRouterParams(adapter Adapter, r *http.Request, route *BaseRoute) map[string]string {
+	adapterPath, _ := r.Context().Value(adapterPathKey).(string)
+	if adapterPath == "" {
+		return adapter.ExtractRouterParams(r, route)
+	}
+
+	adapterRoute := *route
+	adapterRoute.Path = adapterPath
+	params := adapter.ExtractRouterParams(r, &adapterRoute)
+
+	adapterNames, names := pathParamNames(adapterPath), pathParamNames(route.Path)
+	renamed := make(map[string]string, len(params))
+	for name, value := range params {
+		if !slices.Contains(adapterNames, name) {
+			renamed[name] = value
+		}
+	}
+	for i, name := range adapterNames {
+		if value, ok := params[name]; ok && i < len(names) {
+			renamed[names[i]] = value
+		}
+	}
+
+	return renamed
+}
+
+// pathParamNames returns the names of the parameters in a path, in order.
+func pathParamNames(path string) []string {
+	matches := pathParamPattern.FindAllString(path, -1)
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = strings.TrimSuffix(strings.Trim(match, "{}"), "...")
+	}
+
+	return names
+}
@@ -0,0 +1,70 @@
+package zorya
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicHandler is called when an operation panics, with the recovered value and
+// the stack trace of the panicking goroutine, e.g. to report the panic to an
+// error tracker. It runs before the 500 Internal Server Error response is
+// written.
+type PanicHandler func(r *http.Request, recovered any, stack []byte)
+
+// newRecoverMiddleware creates middleware that recovers from panics in route
+// middlewares, the handler, transformers and body functions. The panic is
+// passed to the API's PanicHandler (or logged if none is configured) and a 500
+// Internal Server Error is written if the response has not been started yet,
+// without the headers set for the response that failed.
+// http.ErrAbortHandler is re-panicked to abort the response as net/http does.
+func newRecoverMiddleware(api API) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracker := &writeHeaderTracker{ResponseWriter: w}
+			initial := w.Header().Clone()
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				stack := debug.Stack()
+				if handler := api.PanicHandler(); handler != nil {
+					handler(r, recovered, stack)
+				} else {
+					log.Printf("zorya: panic serving %s %s: %v\n%s", r.Method, r.URL.Path, recovered, stack)
+				}
+
+				if !tracker.written {
+					resetHeaders(tracker.Header(), initial)
+					WriteErr(api, r, tracker.ResponseWriter, http.StatusInternalServerError, "Internal Server Error")
+				}
+			}()
+
+			next.ServeHTTP(tracker, r)
+		})
+	}
+}
+
+// resetHeaders removes the headers set after initial was taken, e.g. the
+// Content-Type, ETag or Content-Encoding of the failed response, so that they
+// are not sent with the error. CORS and Vary headers are kept, so that
+// browsers can read the error.
+func resetHeaders(header, initial http.Header) {
+	for name := range header {
+		if _, ok := initial[name]; ok || name == "Vary" || strings.HasPrefix(name, "Access-Control-") {
+			continue
+		}
+		delete(header, name)
+	}
+	for name, values := range initial {
+		header[name] = values
+	}
+}
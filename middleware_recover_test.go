@@ -0,0 +1,102 @@
+package zorya
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecover_ResetsHeaders(t *testing.T) {
+	type StreamOutput struct {
+		Body func(http.ResponseWriter) error
+	}
+
+	router := chi.NewMux()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-Id", "42")
+			next.ServeHTTP(w, r)
+		})
+	})
+	api := NewAPI(&testChiAdapter{router: router},
+		WithCORS(&CORS{AllowOrigins: []string{"https://app.example.com"}}),
+		WithPanicHandler(func(r *http.Request, recovered any, stack []byte) {}))
+
+	Get(api, "/export", func(ctx context.Context, _ *struct{}) (*StreamOutput, error) {
+		return &StreamOutput{Body: func(w http.ResponseWriter) error {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Disposition", "attachment")
+			panic("boom")
+		}}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	header := recorder.Header()
+	assert.Equal(t, "application/problem+json", header.Get("Content-Type"))
+	assert.Empty(t, header.Get("ETag"))
+	assert.Empty(t, header.Get("Content-Encoding"))
+	assert.Empty(t, header.Get("Content-Disposition"))
+
+	// Headers set before the route and CORS headers are kept
+	assert.Equal(t, "42", header.Get("X-Request-Id"))
+	assert.Equal(t, "https://app.example.com", header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, header.Values("Vary"), "Origin")
+}
+
+func TestPanicRecovery(t *testing.T) {
+	type StreamingOutput struct {
+		Body func(w http.ResponseWriter) error
+	}
+
+	var recovered []any
+	var stacks [][]byte
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter, WithPanicHandler(func(r *http.Request, rec any, stack []byte) {
+		recovered = append(recovered, rec)
+		stacks = append(stacks, stack)
+	}))
+
+	Get(api, "/handler", func(ctx context.Context, _ *struct{}) (*GetUserOutput, error) {
+		panic("handler failed")
+	})
+	Get(api, "/stream", func(ctx context.Context, _ *struct{}) (*StreamingOutput, error) {
+		return &StreamingOutput{Body: func(w http.ResponseWriter) error {
+			_, _ = w.Write([]byte("partial"))
+			panic("stream failed")
+		}}, nil
+	})
+
+	// Panic before the response started is turned into a 500 error
+	req := httptest.NewRequest(http.MethodGet, "/handler", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"title": "Internal Server Error", "status": 500, "detail": "Internal Server Error"}`, recorder.Body.String())
+
+	// Panic after the response started leaves the response as is
+	req = httptest.NewRequest(http.MethodGet, "/stream", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "partial", recorder.Body.String())
+
+	require.Equal(t, []any{"handler failed", "stream failed"}, recovered)
+	assert.Contains(t, string(stacks[0]), "TestPanicRecovery")
+}
@@ -2,6 +2,7 @@ package zorya
 
 import (
 	"context"
+	"errors"
 	"net/http"
 )
 
@@ -74,3 +75,55 @@ func newSecurityMetadataMiddleware(security *RouteSecurity) Middleware {
 		})
 	}
 }
+
+// newAuthorizationMiddleware creates middleware that enforces the route's security
+// requirements using the API's Authorizer. It must run after the security metadata
+// middleware, which stores the resolved requirements in context.
+// Returns nil if the route has no security requirements or no Authorizer is configured.
+func newAuthorizationMiddleware(api API, security *RouteSecurity) Middleware {
+	authorizer := api.Authorizer()
+	if security == nil || authorizer == nil {
+		return nil
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authorizer.Authenticate(r)
+			if err != nil {
+				writeSecurityErr(api, r, w, http.StatusUnauthorized, "authentication failed", err)
+
+				return
+			}
+			if principal == nil {
+				WriteErr(api, r, w, http.StatusUnauthorized, "authentication required")
+
+				return
+			}
+
+			if err := authorizer.Authorize(r, principal, GetRouteSecurityContext(r)); err != nil {
+				writeSecurityErr(api, r, w, http.StatusForbidden, "access denied", err)
+
+				return
+			}
+
+			// Store principal in context for handlers
+			r = r.WithContext(context.WithValue(r.Context(), principalContextKey, principal))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeSecurityErr writes err as is if it carries a status code. Other errors are
+// replaced by a generic error with the given status so that authorizer internals
+// are not leaked to clients.
+func writeSecurityErr(api API, r *http.Request, w http.ResponseWriter, status int, msg string, err error) {
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		WriteErr(api, r, w, 0, "", err)
+
+		return
+	}
+
+	WriteErr(api, r, w, status, msg)
+}
@@ -0,0 +1,242 @@
+package zorya
+
+import (
+	"slices"
+
+	"github.com/talav/zorya/internal/openapidoc"
+)
+
+// openAPIVersion30 is the version of the downgraded spec, for tools that do
+// not support OpenAPI 3.1.
+const openAPIVersion30 = "3.0.3"
+
+// unsupportedSchemaKeywords are the JSON Schema 2020-12 keywords that OpenAPI
+// 3.0 schemas cannot express. They are dropped by the downgrade.
+var unsupportedSchemaKeywords = []string{
+	"$schema", "$id", "$anchor", "$comment", "$defs", "$dynamicAnchor", "$dynamicRef",
+	"if", "then", "else", "dependentRequired", "dependentSchemas",
+	"prefixItems", "contains", "minContains", "maxContains", "unevaluatedItems",
+	"unevaluatedProperties", "patternProperties", "propertyNames", "contentSchema",
+}
+
+// downgradeSpec converts a generated OpenAPI 3.1 document to OpenAPI 3.0.3 in
+// place: schemas are rewritten to their 3.0 equivalents (see downgradeSchema),
+// and fields 3.0 does not have, including webhooks and QUERY and custom method
+// operations, are removed.
+func downgradeSpec(doc map[string]any) {
+	doc["openapi"] = openAPIVersion30
+	delete(doc, "jsonSchemaDialect")
+	delete(doc, "webhooks")
+	delete(doc, "$self")
+
+	if info, ok := doc["info"].(map[string]any); ok {
+		delete(info, "summary")
+		if license, ok := info["license"].(map[string]any); ok {
+			delete(license, "identifier")
+		}
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	if paths == nil {
+		// Paths are optional since 3.1 only
+		paths = map[string]any{}
+		doc["paths"] = paths
+	}
+	for path, value := range paths {
+		pathItem, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		delete(pathItem, openapidoc.QueryField)
+		delete(pathItem, openapidoc.AdditionalOperationsField)
+		if len(pathItem) == 0 {
+			delete(paths, path)
+		}
+	}
+
+	for key, value := range doc {
+		if key != "components" {
+			downgradeSchemaFields(value)
+		}
+	}
+
+	components, _ := doc["components"].(map[string]any)
+	delete(components, "pathItems")
+	for key, value := range components {
+		if key != "schemas" {
+			downgradeSchemaFields(value)
+			continue
+		}
+
+		schemas, _ := value.(map[string]any)
+		for _, schema := range schemas {
+			if s, ok := schema.(map[string]any); ok {
+				downgradeSchema(s)
+			}
+		}
+	}
+}
+
+// downgradeSchemaFields downgrades the schemas of parameters, headers and media
+// types found in v.
+func downgradeSchemaFields(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(map[string]any); ok && key == "schema" {
+				downgradeSchema(s)
+				continue
+			}
+			downgradeSchemaFields(value)
+		}
+
+	case []any:
+		for _, value := range v {
+			downgradeSchemaFields(value)
+		}
+
+	default:
+	}
+}
+
+// downgradeSchema converts a JSON Schema 2020-12 schema and its subschemas to
+// an OpenAPI 3.0 schema in place:
+//   - "null" in type arrays and null anyOf/oneOf members become nullable,
+//     several remaining types an anyOf,
+//   - const becomes a single-value enum,
+//   - examples becomes example, with the first example,
+//   - numeric exclusiveMinimum/exclusiveMaximum become the boolean form,
+//   - $ref with sibling keywords is wrapped in allOf,
+//   - keywords 3.0 does not support are removed.
+func downgradeSchema(s map[string]any) {
+	nullable := false
+
+	switch t := s["type"].(type) {
+	case []any:
+		var types []any
+		for _, name := range t {
+			if name == "null" {
+				nullable = true
+			} else {
+				types = append(types, name)
+			}
+		}
+
+		delete(s, "type")
+		switch len(types) {
+		case 0:
+		case 1:
+			s["type"] = types[0]
+		default:
+			anyOf := make([]any, 0, len(types))
+			for _, name := range types {
+				anyOf = append(anyOf, map[string]any{"type": name})
+			}
+			addAllOf(s, map[string]any{"anyOf": anyOf})
+		}
+
+	case string:
+		if t == "null" {
+			delete(s, "type")
+			nullable = true
+		}
+
+	default:
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		members, ok := s[key].([]any)
+		if !ok {
+			continue
+		}
+		remaining := slices.DeleteFunc(slices.Clone(members), isNullSchema)
+		if len(remaining) < len(members) {
+			nullable = true
+			s[key] = remaining
+		}
+		if len(remaining) == 0 {
+			delete(s, key)
+		}
+	}
+
+	if value, ok := s["const"]; ok {
+		if _, hasEnum := s["enum"]; !hasEnum {
+			s["enum"] = []any{value}
+		}
+		delete(s, "const")
+	}
+
+	if examples, ok := s["examples"].([]any); ok {
+		if _, hasExample := s["example"]; !hasExample && len(examples) > 0 {
+			s["example"] = examples[0]
+		}
+		delete(s, "examples")
+	}
+
+	for exclusive, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		if value, ok := s[exclusive]; ok {
+			if _, isBool := value.(bool); !isBool {
+				s[bound] = value
+				s[exclusive] = true
+			}
+		}
+	}
+
+	if s["contentEncoding"] == "base64" {
+		s["format"] = "byte"
+	}
+	delete(s, "contentEncoding")
+	delete(s, "contentMediaType")
+
+	for _, keyword := range unsupportedSchemaKeywords {
+		delete(s, keyword)
+	}
+
+	if nullable {
+		s["nullable"] = true
+	}
+
+	if ref, ok := s["$ref"]; ok && len(s) > 1 {
+		// Keywords next to $ref are ignored in 3.0
+		delete(s, "$ref")
+		addAllOf(s, map[string]any{"$ref": ref})
+	}
+
+	// Subschemas
+	properties, _ := s["properties"].(map[string]any)
+	for _, property := range properties {
+		if sub, ok := property.(map[string]any); ok {
+			downgradeSchema(sub)
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := s[key].(map[string]any); ok {
+			downgradeSchema(sub)
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		members, _ := s[key].([]any)
+		for _, member := range members {
+			if sub, ok := member.(map[string]any); ok {
+				downgradeSchema(sub)
+			}
+		}
+	}
+}
+
+// addAllOf adds the subschema to the allOf keyword of s.
+func addAllOf(s map[string]any, sub map[string]any) {
+	allOf, _ := s["allOf"].([]any)
+	s["allOf"] = append([]any{sub}, allOf...)
+}
+
+// isNullSchema reports whether v is a schema only allowing null.
+func isNullSchema(v any) bool {
+	s, ok := v.(map[string]any)
+	if !ok || len(s) != 1 {
+		return false
+	}
+	types, _ := s["type"].([]any)
+
+	return s["type"] == "null" || slices.Equal(types, []any{"null"})
+}
@@ -0,0 +1,72 @@
+package zorya
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDowngradeSpec(t *testing.T) {
+	doc := map[string]any{
+		"openapi":           "3.1.2",
+		"jsonSchemaDialect": "https://spec.openapis.org/oas/3.1/dialect/base",
+		"info":              map[string]any{"title": "API", "version": "1.0.0", "summary": "Pets"},
+		"webhooks":          map[string]any{"newPet": map[string]any{}},
+		"paths": map[string]any{
+			"/pets": map[string]any{
+				"get": map[string]any{
+					"parameters": []any{map[string]any{
+						"name":   "limit",
+						"in":     "query",
+						"schema": map[string]any{"type": "integer", "exclusiveMinimum": json.Number("0"), "examples": []any{10}},
+					}},
+				},
+				"x-query":                map[string]any{},
+				"x-additionalOperations": map[string]any{"PURGE": map[string]any{}},
+			},
+			"/search": map[string]any{"x-query": map[string]any{}},
+		},
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"Pet": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"name":  map[string]any{"type": []any{"string", "null"}},
+						"kind":  map[string]any{"const": "dog"},
+						"id":    map[string]any{"type": []any{"string", "integer"}},
+						"owner": map[string]any{"$ref": "#/components/schemas/Owner", "description": "Owner of the pet"},
+						"tag":   map[string]any{"anyOf": []any{map[string]any{"$ref": "#/components/schemas/Tag"}, map[string]any{"type": "null"}}},
+						"photo": map[string]any{"type": "string", "contentEncoding": "base64"},
+					},
+					"unevaluatedProperties": false,
+				},
+			},
+		},
+	}
+
+	downgradeSpec(doc)
+
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.NotContains(t, doc, "jsonSchemaDialect")
+	assert.NotContains(t, doc, "webhooks")
+	assert.Equal(t, map[string]any{"title": "API", "version": "1.0.0"}, doc["info"])
+
+	paths := doc["paths"].(map[string]any)
+	assert.NotContains(t, paths, "/search", "path items left without operations must be removed")
+	pets := paths["/pets"].(map[string]any)
+	assert.NotContains(t, pets, "x-query")
+	assert.NotContains(t, pets, "x-additionalOperations")
+	param := pets["get"].(map[string]any)["parameters"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "integer", "minimum": json.Number("0"), "exclusiveMinimum": true, "example": 10}, param["schema"])
+
+	pet := doc["components"].(map[string]any)["schemas"].(map[string]any)["Pet"].(map[string]any)
+	assert.NotContains(t, pet, "unevaluatedProperties")
+	properties := pet["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string", "nullable": true}, properties["name"])
+	assert.Equal(t, map[string]any{"enum": []any{"dog"}}, properties["kind"])
+	assert.Equal(t, map[string]any{"allOf": []any{map[string]any{"anyOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}}}}}, properties["id"])
+	assert.Equal(t, map[string]any{"allOf": []any{map[string]any{"$ref": "#/components/schemas/Owner"}}, "description": "Owner of the pet"}, properties["owner"])
+	assert.Equal(t, map[string]any{"anyOf": []any{map[string]any{"$ref": "#/components/schemas/Tag"}}, "nullable": true}, properties["tag"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "byte"}, properties["photo"])
+}
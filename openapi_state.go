@@ -1,12 +1,18 @@
 package zorya
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
 	"sync"
 
 	"github.com/talav/openapi"
+	"github.com/talav/zorya/internal/openapidoc"
 )
 
 // openapiState manages OpenAPI specification state.
@@ -14,10 +20,30 @@ import (
 type openapiState struct {
 	openapiAPI *openapi.API
 	operations []openapi.Operation
+	routes     []*BaseRoute // Route of each operation, used for spec overrides
+
+	// spec is the API's OpenAPI document. Fields the generator does not
+	// support (e.g. security schemes) are read from it at generation time.
+	spec *OpenAPI
+
+	// Media types, besides JSON, that structured request bodies are accepted
+	// in, and that response bodies and errors can be negotiated to.
+	requestContentTypes  []string
+	responseContentTypes []string
+	problemContentTypes  []string
+
+	// schemasPath is the path prefix individual schemas are served under, used
+	// for $id and $ref of standalone schema documents. Empty if not served.
+	schemasPath string
 
 	// Cache for lazy generation
-	specCache []byte
-	specETag  string
+	specCache    []byte
+	specETag     string
+	specVariants map[specFormat]specVariant // YAML and OpenAPI 3.0 encodings of the spec
+	schemaCache  map[string][]byte          // Standalone JSON Schema documents by component name
+
+	// Checks responses against the generated document
+	responseValidator *openapidoc.ResponseValidator
 
 	mu sync.RWMutex
 }
@@ -31,54 +57,705 @@ func newOpenapiState(a *api) *openapiState {
 	return &openapiState{
 		openapiAPI: openapiAPI,
 		operations: make([]openapi.Operation, 0),
+		routes:     make([]*BaseRoute, 0),
+		spec:       a.openAPI,
+
+		requestContentTypes:  withoutJSON(a.requestFormatKeys),
+		responseContentTypes: withoutJSON(a.formatKeys),
+		problemContentTypes:  problemContentTypes(a.formatKeys),
+		schemasPath:          a.config.SchemasPath,
 	}
 }
 
-// AddOperation adds an operation to the OpenAPI spec.
+// withoutJSON returns the media types other than application/json.
+func withoutJSON(contentTypes []string) []string {
+	return slices.DeleteFunc(slices.Clone(contentTypes), func(ct string) bool {
+		return ct == contentTypeJSON
+	})
+}
+
+// problemContentTypes returns the media types, besides application/problem+json,
+// that ErrorModel responses are written in for the given negotiable media types.
+func problemContentTypes(contentTypes []string) []string {
+	result := make([]string, 0, len(contentTypes))
+	for _, ct := range contentTypes {
+		problemCT := (&ErrorModel{}).ContentType(ct)
+		if problemCT != contentTypeProblemJSON && !slices.Contains(result, problemCT) {
+			result = append(result, problemCT)
+		}
+	}
+
+	return result
+}
+
+// AddOperation adds an operation generated for the given route to the OpenAPI spec.
 // This invalidates the cached spec.
-func (s *openapiState) AddOperation(op openapi.Operation) {
+func (s *openapiState) AddOperation(op openapi.Operation, route *BaseRoute) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.operations = append(s.operations, op)
+	s.routes = append(s.routes, route)
 
 	// Invalidate cache
 	s.specCache = nil
 	s.specETag = ""
+	s.specVariants = nil
+	s.schemaCache = nil
+	s.responseValidator = nil
 }
 
 // GenerateSpec generates the OpenAPI specification.
 // Results are cached until a new operation is added.
 func (s *openapiState) GenerateSpec(ctx context.Context) ([]byte, string, error) {
-	// Fast path: check cache with read lock
+	if err := s.ensureGenerated(ctx); err != nil {
+		return nil, "", err
+	}
+
 	s.mu.RLock()
-	if s.specCache != nil {
-		cache, etag := s.specCache, s.specETag
-		s.mu.RUnlock()
-		return cache, etag, nil
+	defer s.mu.RUnlock()
+
+	return s.specCache, s.specETag, nil
+}
+
+// specFormat selects an encoding of the spec.
+type specFormat struct {
+	yaml      bool // YAML instead of JSON
+	openAPI30 bool // Downgraded to OpenAPI 3.0.3
+}
+
+// specVariant is a cached encoding of the spec.
+type specVariant struct {
+	data []byte
+	etag string
+}
+
+// GenerateSpecFormat returns the OpenAPI specification in the given format,
+// along with its ETag. Results are cached until a new operation is added.
+func (s *openapiState) GenerateSpecFormat(ctx context.Context, format specFormat) ([]byte, string, error) {
+	if format == (specFormat{}) {
+		return s.GenerateSpec(ctx)
+	}
+
+	// Generate under the same lock the variant is stored with, so that a
+	// concurrent AddOperation cannot invalidate the cache in between
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.generateLocked(ctx); err != nil {
+		return nil, "", err
+	}
+	if variant, ok := s.specVariants[format]; ok {
+		return variant.data, variant.etag, nil
+	}
+
+	data := s.specCache
+	if format.openAPI30 {
+		// Decode the cached JSON again to downgrade a copy of the document
+		doc, err := decodeJSONObject(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode spec: %w", err)
+		}
+		order, err := readJSONKeyOrder(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode spec: %w", err)
+		}
+		downgradeSpec(doc)
+
+		if data, err = encodeOrderedJSON(doc, order); err != nil {
+			return nil, "", fmt.Errorf("failed to encode spec: %w", err)
+		}
 	}
+
+	if format.yaml {
+		var buf bytes.Buffer
+		if err := writeYAML(&buf, data); err != nil {
+			return nil, "", fmt.Errorf("failed to encode spec as YAML: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	variant := specVariant{data: data, etag: fmt.Sprintf(`"%x"`, sha256.Sum256(data))}
+	s.specVariants[format] = variant
+
+	return variant.data, variant.etag, nil
+}
+
+// Schema returns the standalone JSON Schema document for the named component
+// schema, or nil if there is no such schema.
+func (s *openapiState) Schema(ctx context.Context, name string) ([]byte, error) {
+	if err := s.ensureGenerated(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.schemaCache[name], nil
+}
+
+// ensureGenerated generates and caches the spec and derived documents if needed.
+func (s *openapiState) ensureGenerated(ctx context.Context) error {
+	// Fast path: check cache with read lock
+	s.mu.RLock()
+	cached := s.specCache != nil
 	s.mu.RUnlock()
+	if cached {
+		return nil
+	}
 
 	// Slow path: generate with write lock
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Double-check after acquiring write lock
+	return s.generateLocked(ctx)
+}
+
+// generateLocked generates and caches the spec and derived documents unless
+// they are cached already. The caller must hold the write lock.
+func (s *openapiState) generateLocked(ctx context.Context) error {
 	if s.specCache != nil {
-		return s.specCache, s.specETag, nil
+		return nil
 	}
 
-	// Generate spec using API method
-	result, err := s.openapiAPI.Generate(ctx, s.operations...)
+	// Generate spec using API method, along with placeholder operations for
+	// the data schemas of Server-Sent Events
+	eventOps, eventPaths := s.eventSchemaOperations()
+	result, err := s.generate(ctx, append(slices.Clone(s.operations), eventOps...))
 	if err != nil {
-		return nil, "", err
+		return err
+	}
+
+	// Keep the key order of the generated document
+	order, err := readJSONKeyOrder(result.JSON)
+	if err != nil {
+		return fmt.Errorf("failed to decode generated spec: %w", err)
+	}
+
+	doc, err := s.applyOverrides(result.JSON, order, eventPaths)
+	if err != nil {
+		return err
+	}
+
+	specJSON, err := encodeOrderedJSON(doc, order)
+	if err != nil {
+		return fmt.Errorf("failed to encode spec: %w", err)
+	}
+
+	schemas, err := s.buildSchemaDocuments(doc)
+	if err != nil {
+		return err
 	}
 
 	// Cache the result
-	s.specCache = result.JSON
-	s.specETag = fmt.Sprintf(`"%x"`, sha256.Sum256(result.JSON))
+	s.specCache = specJSON
+	s.specETag = fmt.Sprintf(`"%x"`, sha256.Sum256(specJSON))
+	s.specVariants = make(map[specFormat]specVariant)
+	s.schemaCache = schemas
+	s.setDescribedByLinks(doc)
+	s.responseValidator = openapidoc.NewResponseValidator(doc)
 
-	return s.specCache, s.specETag, nil
+	return nil
+}
+
+// generate runs the generator on the operations. The generator panics on
+// schemas it cannot describe, e.g. two types with the same name, which is
+// returned as an error.
+func (s *openapiState) generate(ctx context.Context, ops []openapi.Operation) (result *openapi.Result, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("failed to generate OpenAPI spec: %v", p)
+		}
+	}()
+
+	return s.openapiAPI.Generate(ctx, ops...)
+}
+
+// ResponseValidator returns the validator checking responses against the
+// generated document, generating the spec if needed.
+func (s *openapiState) ResponseValidator(ctx context.Context) (*openapidoc.ResponseValidator, error) {
+	s.mu.RLock()
+	validator := s.responseValidator
+	s.mu.RUnlock()
+	if validator != nil {
+		return validator, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.generateLocked(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.responseValidator, nil
+}
+
+// applyOverrides merges the parts of the spec that the generator cannot express
+// into the generated document: security schemes, document-level security,
+// per-operation fields from BaseRoute.Operation and BaseRoute.Security, the
+// media types of every registered format for request and response bodies, the
+// text/event-stream responses of Server-Sent Events routes, and CORS preflight
+// operations. Operations moved within the document keep their key order.
+func (s *openapiState) applyOverrides(specJSON []byte, order *jsonKeyOrder, eventPaths map[*BaseRoute]map[string]string) (map[string]any, error) {
+	doc, err := decodeJSONObject(specJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode generated spec: %w", err)
+	}
+
+	if s.spec.Components != nil && len(s.spec.Components.SecuritySchemes) > 0 {
+		components, _ := doc["components"].(map[string]any)
+		if components == nil {
+			components = map[string]any{}
+			doc["components"] = components
+		}
+		if err := mergeJSON(components, "securitySchemes", s.spec.Components.SecuritySchemes); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.spec.Security != nil {
+		doc["security"] = s.spec.Security
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	eventSchemas := extractEventSchemas(paths, eventPaths)
+	for _, route := range s.routes {
+		moveMethodOperation(paths, order.field("paths"), route)
+
+		pathItem, _ := paths[route.Path].(map[string]any)
+		operation := openapidoc.PathItemOperation(pathItem, route.Method)
+		if operation == nil {
+			continue
+		}
+
+		if override := s.operationOverride(route); override != nil {
+			if err := mergeOperation(operation, override); err != nil {
+				return nil, fmt.Errorf("failed to apply operation %s %s: %w", route.Method, route.Path, err)
+			}
+		}
+
+		if route.eventStream {
+			setEventStreamResponse(operation, route, eventSchemas[route])
+		}
+		if route.content {
+			setContentResponse(operation, route)
+		}
+
+		if route.CORS != nil && route.CORS.DocumentPreflight && pathItem["options"] == nil {
+			pathItem["options"] = preflightOperation(operation)
+		}
+
+		addContentTypes(operation["requestBody"], contentTypeJSON, s.requestContentTypes)
+
+		responses, _ := operation["responses"].(map[string]any)
+		for _, response := range responses {
+			addContentTypes(response, contentTypeJSON, s.responseContentTypes)
+			addContentTypes(response, contentTypeProblemJSON, s.problemContentTypes)
+		}
+	}
+
+	return doc, nil
+}
+
+// operationOverride returns the operation fields to apply on top of the generated
+// operation for route, or nil if there are none. Explicit BaseRoute.Operation
+// fields take precedence over values derived from the route.
+func (s *openapiState) operationOverride(route *BaseRoute) *Operation {
+	if route.Operation == nil && route.Security == nil {
+		return nil
+	}
+
+	var op Operation
+	if route.Operation != nil {
+		op = *route.Operation
+	}
+
+	if op.Security == nil && route.Security != nil {
+		op.Security = securityRequirements(route.Security, s.spec)
+	}
+
+	return &op
+}
+
+// addContentTypes adds the given media types to the content map of a request
+// body or response that has a representation in source, reusing its media type
+// object.
+func addContentTypes(target any, source string, contentTypes []string) {
+	object, _ := target.(map[string]any)
+	content, _ := object["content"].(map[string]any)
+
+	media, ok := content[source]
+	if !ok {
+		return
+	}
+
+	for _, ct := range contentTypes {
+		if _, exists := content[ct]; !exists {
+			content[ct] = media
+		}
+	}
+}
+
+// securityRequirements resolves the OpenAPI security requirement objects for a
+// secured route. Explicit requirements are used as is, with scopes derived from
+// the route's roles and permissions for OAuth2 and OpenID Connect schemes when
+// none are given. Routes without explicit requirements inherit the document-level
+// security if set, or else accept any of the declared schemes.
+// Returns nil when the operation should not declare its own security.
+func securityRequirements(sec *RouteSecurity, spec *OpenAPI) []map[string][]string {
+	requirements := sec.Requirements
+	if len(requirements) == 0 {
+		if spec.Security != nil || spec.Components == nil {
+			return nil
+		}
+
+		names := make([]string, 0, len(spec.Components.SecuritySchemes))
+		for name := range spec.Components.SecuritySchemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			requirements = append(requirements, SecurityRequirement{name: nil})
+		}
+	}
+
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	result := make([]map[string][]string, 0, len(requirements))
+	for _, req := range requirements {
+		resolved := make(map[string][]string, len(req))
+		for name, scopes := range req {
+			if scopes == nil {
+				scopes = defaultScopes(sec, spec, name)
+			}
+			resolved[name] = scopes
+		}
+		result = append(result, resolved)
+	}
+
+	return result
+}
+
+// defaultScopes returns the scopes required for a scheme when the route does not
+// list them explicitly. OAuth2 and OpenID Connect scopes are taken from the route's
+// roles and permissions; other scheme types use an empty list as the spec requires.
+func defaultScopes(sec *RouteSecurity, spec *OpenAPI, name string) []string {
+	scopes := []string{}
+
+	var scheme *SecurityScheme
+	if spec.Components != nil {
+		scheme = spec.Components.SecuritySchemes[name]
+	}
+	if scheme != nil && (scheme.Type == "oauth2" || scheme.Type == "openIdConnect") {
+		scopes = append(scopes, sec.Roles...)
+		scopes = append(scopes, sec.Permissions...)
+	}
+
+	return scopes
+}
+
+// mergeOperation deep-merges override into a generated operation object.
+// Parameters are matched by name and location, so explicit parameters replace
+// reflected ones and new parameters are appended.
+func mergeOperation(dst map[string]any, override *Operation) error {
+	value, err := toJSONValue(override)
+	if err != nil {
+		return err
+	}
+
+	src, _ := value.(map[string]any)
+	for k, v := range src {
+		if v == nil {
+			continue
+		}
+		if k == "parameters" {
+			dst[k] = mergeParameters(dst[k], v)
+
+			continue
+		}
+		dst[k] = mergeValues(dst[k], v)
+	}
+
+	return nil
+}
+
+// mergeParameters merges src parameters into dst, replacing parameters with the
+// same name and location.
+func mergeParameters(dst, src any) any {
+	dstList, _ := dst.([]any)
+	srcList, _ := src.([]any)
+
+	for _, param := range srcList {
+		replaced := false
+		for i, existing := range dstList {
+			if sameParameter(existing, param) {
+				dstList[i] = mergeValues(existing, param)
+				replaced = true
+
+				break
+			}
+		}
+		if !replaced {
+			dstList = append(dstList, param)
+		}
+	}
+
+	return dstList
+}
+
+// sameParameter reports whether two parameter objects share name and location.
+func sameParameter(a, b any) bool {
+	pa, _ := a.(map[string]any)
+	pb, _ := b.(map[string]any)
+	if pa == nil || pb == nil || pa["name"] == nil {
+		return false
+	}
+
+	return pa["name"] == pb["name"] && pa["in"] == pb["in"]
+}
+
+// mergeJSON marshals value and deep-merges it into dst[key]. Objects are merged
+// recursively; any other value replaces the existing one.
+func mergeJSON(dst map[string]any, key string, value any) error {
+	src, err := toJSONValue(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	dst[key] = mergeValues(dst[key], src)
+
+	return nil
+}
+
+// mergeValues deep-merges src into dst, with src taking precedence. A null src
+// keeps dst, and an object with a $ref replaces dst entirely.
+func mergeValues(dst, src any) any {
+	if src == nil {
+		return dst
+	}
+
+	dstMap, ok := dst.(map[string]any)
+	if !ok {
+		return src
+	}
+	srcMap, ok := src.(map[string]any)
+	if !ok {
+		return src
+	}
+	if _, isRef := srcMap["$ref"]; isRef {
+		return srcMap
+	}
+
+	for k, v := range srcMap {
+		if v != nil {
+			dstMap[k] = mergeValues(dstMap[k], v)
+		}
+	}
+
+	return dstMap
+}
+
+// decodeJSONValue decodes a JSON value, preserving number precision.
+func decodeJSONValue(data []byte) (any, error) {
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// toJSONValue converts v to its generic JSON representation, preserving number precision.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSONValue(data)
+}
+
+// decodeJSONObject decodes a JSON object, preserving number precision.
+func decodeJSONObject(data []byte) (map[string]any, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// encodeJSON encodes v without escaping HTML characters.
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// jsonKeyOrder is the key order of a JSON object, and of the objects nested in
+// it, as read from an encoded document.
+type jsonKeyOrder struct {
+	keys   []string
+	fields map[string]*jsonKeyOrder // Nested objects and arrays by key
+	items  []*jsonKeyOrder          // Nested objects and arrays by index
+}
+
+// readJSONKeyOrder reads the key order of the objects of a JSON document.
+func readJSONKeyOrder(data []byte) (*jsonKeyOrder, error) {
+	return readJSONValueOrder(json.NewDecoder(bytes.NewReader(data)))
+}
+
+// readJSONValueOrder reads the next value from dec and returns its key order,
+// or nil if it is neither an object nor an array.
+func readJSONValueOrder(dec *json.Decoder) (*jsonKeyOrder, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil, nil
+	}
+
+	order := &jsonKeyOrder{}
+	for dec.More() {
+		if delim == '[' {
+			item, err := readJSONValueOrder(dec)
+			if err != nil {
+				return nil, err
+			}
+			order.items = append(order.items, item)
+
+			continue
+		}
+
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		field, err := readJSONValueOrder(dec)
+		if err != nil {
+			return nil, err
+		}
+		order.set(key, field)
+	}
+
+	// Closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// field returns the key order of the value at key, or nil if it is not known.
+func (o *jsonKeyOrder) field(key string) *jsonKeyOrder {
+	if o == nil {
+		return nil
+	}
+
+	return o.fields[key]
+}
+
+// set sets the key order of the value at key, appending key to the keys if it
+// is not listed yet.
+func (o *jsonKeyOrder) set(key string, field *jsonKeyOrder) {
+	if !slices.Contains(o.keys, key) {
+		o.keys = append(o.keys, key)
+	}
+	if o.fields == nil {
+		o.fields = map[string]*jsonKeyOrder{}
+	}
+	o.fields[key] = field
+}
+
+// encodeOrderedJSON encodes v like encodeJSON, with the keys of its objects in
+// the given order. Keys the order does not list, e.g. added after decoding,
+// follow in sorted order.
+func encodeOrderedJSON(v any, order *jsonKeyOrder) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeOrderedJSON(&buf, v, order); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeOrderedJSON(buf *bytes.Buffer, v any, order *jsonKeyOrder) error {
+	if order == nil {
+		order = &jsonKeyOrder{}
+	}
+
+	switch value := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for _, key := range order.keys {
+			if _, ok := value[key]; ok {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			if !slices.Contains(order.keys, key) {
+				keys = append(keys, key)
+			}
+		}
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodedKey, err := encodeJSON(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(encodedKey)
+			buf.WriteByte(':')
+			if err := writeOrderedJSON(buf, value[key], order.field(key)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			var itemOrder *jsonKeyOrder
+			if i < len(order.items) {
+				itemOrder = order.items[i]
+			}
+			if err := writeOrderedJSON(buf, item, itemOrder); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	default:
+		data, err := encodeJSON(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+
+	return nil
 }
 
 // buildOpenapiOptions creates openapi.Option slice from Zorya's configuration.
@@ -99,17 +776,8 @@ func buildOpenapiOptions(a *api) []openapi.Option {
 			openapi.WithServerDescription(server.Description)))
 	}
 
-	// Add security schemes
-	if a.openAPI.Components != nil && a.openAPI.Components.SecuritySchemes != nil {
-		for name, scheme := range a.openAPI.Components.SecuritySchemes {
-			if scheme.Type == "http" && scheme.Scheme == "bearer" {
-				opts = append(opts, openapi.WithBearerAuth(name, scheme.Description))
-			} else if scheme.Type == "apiKey" {
-				opts = append(opts, openapi.WithAPIKey(name, scheme.Name,
-					openapi.ParameterLocation(scheme.In), scheme.Description))
-			}
-		}
-	}
+	// Security schemes and requirements are merged into the generated
+	// document by applyOverrides, which supports all scheme types.
 
 	return opts
 }
@@ -0,0 +1,137 @@
+package zorya
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+
+	"github.com/talav/zorya/internal/openapidoc"
+)
+
+// ResponseValidationMode selects how responses that do not match the operation
+// documented in the OpenAPI spec are handled. See WithResponseValidation.
+type ResponseValidationMode int
+
+const (
+	// ResponseValidationOff disables response validation. This is the default.
+	ResponseValidationOff ResponseValidationMode = iota
+
+	// ResponseValidationLog logs invalid responses and sends them unchanged.
+	ResponseValidationLog
+
+	// ResponseValidationStrict logs invalid responses and replaces them with a
+	// 500 Internal Server Error.
+	ResponseValidationStrict
+)
+
+// newResponseValidationMiddleware creates middleware that buffers the response
+// of the handler and checks it against the route's documented responses before
+// sending it. Returns nil if response validation is disabled or the route
+// streams its response body.
+func newResponseValidationMiddleware(api API, route *BaseRoute, outputType reflect.Type) Middleware {
+	mode := api.responseValidation()
+	if mode == ResponseValidationOff || hasStreamingBody(outputType) {
+		return nil
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &responseBuffer{header: make(http.Header), status: http.StatusOK}
+			var handlerStatus int
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), handlerStatusKey{}, &handlerStatus)))
+
+			if err := api.checkResponse(r, route, rec.status, handlerStatus, rec.header, rec.body.Bytes()); err != nil {
+				log.Printf("zorya: invalid response for %s %s: %v", r.Method, r.URL.Path, err)
+
+				if mode == ResponseValidationStrict {
+					WriteErr(api, r, w, http.StatusInternalServerError, "response does not match the OpenAPI spec", err)
+
+					return
+				}
+			}
+
+			header := w.Header()
+			for name, values := range rec.header {
+				header[name] = values
+			}
+			w.WriteHeader(rec.status)
+			_, _ = w.Write(rec.body.Bytes())
+		})
+	}
+}
+
+// hasStreamingBody reports whether the output type's body is written as a
+// stream (a body function, an EventStream or a Content), which cannot be
+// buffered.
+func hasStreamingBody(outputType reflect.Type) bool {
+	field, ok := outputType.FieldByName("Body")
+
+	return ok && (field.Type.Kind() == reflect.Func || field.Type == eventStreamType || field.Type == contentBodyType)
+}
+
+// handlerStatusKey is the context key of the status the handler responded
+// with, recorded for response validation.
+type handlerStatusKey struct{}
+
+// setHandlerStatus records the status the handler responded with: the status
+// of its output or of the error it returned.
+func setHandlerStatus(r *http.Request, status int) {
+	if handlerStatus, ok := r.Context().Value(handlerStatusKey{}).(*int); ok {
+		*handlerStatus = status
+	}
+}
+
+// checkResponse checks a response against the operation generated for route:
+// the status must be documented, the media type must be documented for that
+// status, and JSON bodies must match the documented schema. Bodies in other
+// formats are not checked. Statuses other than handlerStatus come from Zorya
+// itself (e.g. request decoding or content negotiation errors) and need not be
+// documented.
+func (a *api) checkResponse(r *http.Request, route *BaseRoute, status, handlerStatus int, header http.Header, body []byte) error {
+	validator, err := a.openapiState.ResponseValidator(r.Context())
+	if err != nil {
+		return err
+	}
+
+	err = validator.Check(route.Path, route.Method, status, header.Get("Content-Type"), body)
+	var undocumented *openapidoc.UndocumentedStatusError
+	if errors.As(err, &undocumented) && status != handlerStatus {
+		return nil
+	}
+
+	return err
+}
+
+func (a *api) responseValidation() ResponseValidationMode {
+	return a.responseValidationMode
+}
+
+// responseBuffer is an http.ResponseWriter that buffers the response so that
+// it can be checked before it is sent.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.wrote {
+		return
+	}
+	b.status = status
+	b.wrote = true
+}
+
+func (b *responseBuffer) Write(data []byte) (int, error) {
+	b.wrote = true
+
+	return b.body.Write(data)
+}
@@ -0,0 +1,101 @@
+package zorya
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseValidation(t *testing.T) {
+	type ItemInput struct {
+		ID int `schema:"id,location=path,required=true"`
+	}
+	type ItemOutput struct {
+		Body struct {
+			ID   int    `json:"id" validate:"min=1"`
+			Name string `json:"name"`
+		} `body:"structured"`
+	}
+
+	newRouter := func(mode ResponseValidationMode) chi.Router {
+		router := chi.NewMux()
+		config := DefaultConfig()
+		config.NoFormatFallback = true
+		api := NewAPI(&testChiAdapter{router: router}, WithConfig(config), WithResponseValidation(mode))
+
+		handler := func(ctx context.Context, input *ItemInput) (*ItemOutput, error) {
+			if input.ID == 404 {
+				return nil, Error404NotFound("item not found")
+			}
+
+			out := &ItemOutput{}
+			out.Body.ID = input.ID
+			out.Body.Name = "item"
+
+			return out, nil
+		}
+		Get(api, "/items/{id}", handler)
+		Get(api, "/documented/{id}", handler, func(r *BaseRoute) {
+			r.Errors = []int{http.StatusNotFound}
+		})
+
+		// Breaks the documented minimum of the body of /broken
+		api.UseTransformer(func(r *http.Request, status int, v any) (any, error) {
+			if out, ok := v.(*ItemOutput); ok && strings.HasPrefix(r.URL.Path, "/broken") {
+				out.Body.ID = 0
+			}
+
+			return v, nil
+		})
+		Get(api, "/broken/{id}", handler)
+
+		return router
+	}
+
+	serve := func(router chi.Router, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// Disabled by default: responses are sent as is
+	router := newRouter(ResponseValidationOff)
+	assert.Equal(t, http.StatusNotFound, serve(router, "/items/404").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "/broken/1").Code)
+
+	// Log mode reports invalid responses but sends them unchanged
+	router = newRouter(ResponseValidationLog)
+	assert.Equal(t, http.StatusNotFound, serve(router, "/items/404").Code)
+	assert.Equal(t, http.StatusOK, serve(router, "/broken/1").Code)
+
+	// Strict mode replaces invalid responses with a 500 error
+	router = newRouter(ResponseValidationStrict)
+
+	recorder := serve(router, "/items/1")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"id":1,"name":"item"}`, recorder.Body.String())
+
+	recorder = serve(router, "/items/404")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "status 404 is not documented")
+
+	assert.Equal(t, http.StatusNotFound, serve(router, "/documented/404").Code)
+
+	// Responses Zorya sends itself need not be documented
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("Accept", "text/plain")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotAcceptable, recorder.Code)
+
+	recorder = serve(router, "/broken/1")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "response does not match the OpenAPI spec")
+}
@@ -2,6 +2,9 @@ package zorya
 
 import (
 	"net/http"
+	"reflect"
+	"slices"
+	"sync/atomic"
 	"time"
 )
 
@@ -45,6 +48,27 @@ type BaseRoute struct {
 	// If < 0, disables the limit (no size restriction).
 	MaxBodyBytes int64
 
+	// UnbufferedResponse writes the response body directly to the client
+	// instead of marshaling it into a buffer first. Use it for large bodies;
+	// a marshaling failure can then no longer be turned into a 500 error and
+	// is only reported to the MarshalErrorHandler.
+	UnbufferedResponse bool
+
+	// DisableCompression sends the response body uncompressed even if
+	// compression is enabled with WithCompression, e.g. for bodies that are
+	// already compressed. Event streams are never compressed.
+	DisableCompression bool
+
+	// Events maps Server-Sent Event names to a sample value of their data
+	// type, for routes whose output Body is an EventStream. See Events.
+	Events map[string]any
+
+	// HeartbeatInterval sets how often a heartbeat comment is sent on idle
+	// Server-Sent Events streams.
+	// If == 0, uses DefaultHeartbeatInterval (15 seconds).
+	// If < 0, disables heartbeats.
+	HeartbeatInterval time.Duration
+
 	// Errors is a list of HTTP status codes that the handler may return. If
 	// not specified, then a default error response is added to the OpenAPI.
 	// This is a convenience for handlers that return a fixed set of errors
@@ -57,6 +81,72 @@ type BaseRoute struct {
 	// Routes without Security are public by default (anonymous access allowed).
 	// Adding any security requirement makes the route protected.
 	Security *RouteSecurity
+
+	// CORS overrides the CORS configuration of the API (see WithCORS) or the
+	// group (see Group.UseCORS) for this route. An empty CORS disables
+	// cross-origin requests.
+	CORS *CORS
+
+	// eventStream is set during registration when the output Body is an EventStream.
+	eventStream bool
+
+	// content is set during registration when the output Body is a Content.
+	content bool
+
+	// inputType and outputType are the handler's input and output structs,
+	// set during registration.
+	inputType  reflect.Type
+	outputType reflect.Type
+
+	// middlewareCount is the number of API, group and route middlewares run
+	// for the route, set during registration.
+	middlewareCount int
+
+	// describedBy is the Link header value pointing to the JSON Schema of the
+	// response body, set when the spec is generated.
+	describedBy *atomic.Pointer[string]
+}
+
+// RouteInfo describes a registered operation, as returned by API.Routes. It is
+// a copy: changing it does not affect the route.
+type RouteInfo struct {
+	// Method is the HTTP method of the operation.
+	Method string
+
+	// Path is the path the operation is served at, including group prefixes.
+	Path string
+
+	// OperationID is the ID set in the route's Operation, if any.
+	OperationID string
+
+	// InputType and OutputType are the handler's input and output structs.
+	InputType  reflect.Type
+	OutputType reflect.Type
+
+	// Middlewares is the number of API, group and route middlewares run for
+	// the operation.
+	Middlewares int
+
+	// Security holds the route's authorization requirements, including those
+	// inherited from its group, or nil if the route is public.
+	Security *RouteSecurity
+}
+
+// routeInfo returns the description of the registered route.
+func routeInfo(route *BaseRoute) RouteInfo {
+	info := RouteInfo{
+		Method:      route.Method,
+		Path:        route.Path,
+		InputType:   route.inputType,
+		OutputType:  route.outputType,
+		Middlewares: route.middlewareCount,
+		Security:    route.Security.clone(),
+	}
+	if route.Operation != nil {
+		info.OperationID = route.Operation.OperationID
+	}
+
+	return info
 }
 
 // RouteSecurity defines authorization requirements for a route.
@@ -79,16 +169,54 @@ type RouteSecurity struct {
 
 	// Action for RBAC
 	Action string
+
+	// Requirements lists the alternative security requirements documented in
+	// the OpenAPI spec (any one must be satisfied). If empty, the route
+	// inherits the document-level security or, if none is set, accepts any
+	// declared security scheme.
+	Requirements []SecurityRequirement
+}
+
+// clone returns a deep copy of the security configuration, or nil if s is nil.
+func (s *RouteSecurity) clone() *RouteSecurity {
+	if s == nil {
+		return nil
+	}
+
+	requirements := make([]SecurityRequirement, 0, len(s.Requirements))
+	for _, req := range s.Requirements {
+		clone := make(SecurityRequirement, len(req))
+		for name, scopes := range req {
+			clone[name] = slices.Clone(scopes)
+		}
+		requirements = append(requirements, clone)
+	}
+
+	return &RouteSecurity{
+		Roles:            slices.Clone(s.Roles),
+		Permissions:      slices.Clone(s.Permissions),
+		Resource:         s.Resource,
+		Action:           s.Action,
+		ResourceResolver: s.ResourceResolver,
+		Requirements:     requirements,
+	}
 }
 
+// SecurityRequirement maps security scheme names declared in
+// Components.SecuritySchemes to the scopes they require. All schemes of a
+// requirement must be satisfied together. A nil scope list is derived from
+// the route's roles and permissions for OAuth2 and OpenID Connect schemes.
+type SecurityRequirement map[string][]string
+
 // SecurityOption configures security requirements for a route.
 type SecurityOption func(*RouteSecurity)
 
 // Secure wraps security options and automatically injects metadata middleware.
 // The metadata middleware resolves resource templates and stores requirements in context.
-// Enforcement is done by the global security middleware (registered via api.UseMiddleware).
+// Enforcement is done by the API's Authorizer (see WithAuthorizer) or, if none is
+// configured, by your own security middleware (registered via api.UseMiddleware).
 //
-// Secure() requires at least one security option (Roles, Permissions, or Resource).
+// Secure() requires at least one security option (Roles, Permissions, Resource, or SecuritySchemes).
 // Routes without Secure() are public by default.
 //
 // Usage:
@@ -109,7 +237,7 @@ type SecurityOption func(*RouteSecurity)
 //	)
 func Secure(opts ...SecurityOption) func(*BaseRoute) {
 	if len(opts) == 0 {
-		panic("zorya.Secure() requires at least one security option. Use Roles(), Permissions(), or Resource() to define security requirements.")
+		panic("zorya.Secure() requires at least one security option. Use Roles(), Permissions(), Resource(), or SecuritySchemes() to define security requirements.")
 	}
 
 	return func(r *BaseRoute) {
@@ -125,7 +253,7 @@ func Secure(opts ...SecurityOption) func(*BaseRoute) {
 
 		// Validate that at least one requirement was set
 		if !hasSecurityRequirements(r.Security) {
-			panic("zorya.Secure() requires at least one security requirement. Use Roles(), Permissions(), or Resource() to define security requirements.")
+			panic("zorya.Secure() requires at least one security requirement. Use Roles(), Permissions(), Resource(), or SecuritySchemes() to define security requirements.")
 		}
 	}
 }
@@ -205,6 +333,39 @@ func ResourceFromRequest(fn func(r *http.Request) string) SecurityOption {
 	}
 }
 
+// SecuritySchemes adds a security requirement where all of the named schemes
+// must be satisfied together. Call it multiple times to declare alternatives.
+// Scheme names must be declared in the OpenAPI Components.SecuritySchemes.
+//
+// Example (bearer token, or API key together with mutual TLS):
+//
+//	zorya.Secure(
+//		zorya.SecuritySchemes("bearerAuth"),
+//		zorya.SecuritySchemes("apiKey", "mtls"),
+//	)
+func SecuritySchemes(names ...string) SecurityOption {
+	return func(s *RouteSecurity) {
+		req := make(SecurityRequirement, len(names))
+		for _, name := range names {
+			req[name] = nil
+		}
+		s.Requirements = append(s.Requirements, req)
+	}
+}
+
+// SecurityRequirements adds alternative security requirements with explicit scopes.
+//
+//	zorya.Secure(
+//		zorya.SecurityRequirements(zorya.SecurityRequirement{
+//			"oauth2": {"read:pets", "write:pets"},
+//		}),
+//	)
+func SecurityRequirements(reqs ...SecurityRequirement) SecurityOption {
+	return func(s *RouteSecurity) {
+		s.Requirements = append(s.Requirements, reqs...)
+	}
+}
+
 // Action sets the RBAC action.
 func Action(action string) SecurityOption {
 	return func(s *RouteSecurity) {
@@ -217,5 +378,6 @@ func hasSecurityRequirements(s *RouteSecurity) bool {
 	return len(s.Roles) > 0 ||
 		len(s.Permissions) > 0 ||
 		s.Resource != "" ||
-		s.ResourceResolver != nil
+		s.ResourceResolver != nil ||
+		len(s.Requirements) > 0
 }
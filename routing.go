@@ -0,0 +1,391 @@
+package zorya
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/talav/zorya/internal/openapidoc"
+)
+
+// pathParamPattern matches path parameters such as {id} or {path...}.
+var pathParamPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// routeTable holds the handlers registered for each path and method. The
+// adapter dispatches requests through it, which lets HEAD, OPTIONS and 405
+// responses be derived from the registered operations.
+type routeTable struct {
+	mu    sync.RWMutex
+	paths map[string]*pathRoutes // By path with parameter names removed
+}
+
+// pathRoutes holds the handlers registered for a path.
+type pathRoutes struct {
+	handlers   map[string]http.Handler // By method
+	routes     map[string]*BaseRoute   // Routes of the handlers by method
+	noHead     bool                    // The GET operation streams events, so HEAD is not derived from it
+	registered map[string]string       // Paths the methods are registered with the adapter for
+}
+
+// newRouteTable creates an empty route table.
+func newRouteTable() *routeTable {
+	return &routeTable{paths: make(map[string]*pathRoutes)}
+}
+
+// routeKey returns the key of a path in the route table. Paths that differ in
+// parameter names only share a key, as routers match them alike.
+func routeKey(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "{}")
+}
+
+// resolveRoutes returns a copy of the route to register, with the API's CORS
+// configuration if it does not set its own.
+func (a *api) resolveRoutes(route *BaseRoute) []*BaseRoute {
+	resolved := *route
+	if resolved.CORS == nil {
+		resolved.CORS = a.cors
+	}
+
+	return []*BaseRoute{&resolved}
+}
+
+// registerRoutes adds the handlers of resolved routes to the route table and
+// registers dispatchers with the adapter for their methods, along with HEAD
+// for GET routes and OPTIONS, unless the path has them already. Nothing is
+// registered if a route's method is registered for its path already.
+func (a *api) registerRoutes(routes []*BaseRoute, handlers []http.Handler) error {
+	methods, err := a.routes.add(routes, handlers)
+	if err != nil {
+		return err
+	}
+
+	for i, route := range routes {
+		for _, method := range methods[i] {
+			methodRoute := *route
+			methodRoute.Method = method
+			a.adapter.Handle(&methodRoute, a.dispatch(routeKey(route.Path), method))
+		}
+	}
+
+	return nil
+}
+
+// add stores the handlers of the routes and returns, for each route, the
+// methods that must be registered with the adapter. Returns an error without
+// storing any handler if a route's method and path are taken.
+func (t *routeTable) add(routes []*BaseRoute, handlers []http.Handler) ([][]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]*BaseRoute)
+	for _, route := range routes {
+		id := route.Method + " " + routeKey(route.Path)
+		other := seen[id]
+		if existing := t.paths[routeKey(route.Path)]; existing != nil && existing.routes[route.Method] != nil {
+			other = existing.routes[route.Method]
+		}
+		if other != nil {
+			return nil, fmt.Errorf("route %s %s conflicts with %s %s", route.Method, route.Path, other.Method, other.Path)
+		}
+		seen[id] = route
+	}
+
+	methods := make([][]string, len(routes))
+	for i, route := range routes {
+		key := routeKey(route.Path)
+		entry, ok := t.paths[key]
+		if !ok {
+			entry = &pathRoutes{
+				handlers:   make(map[string]http.Handler),
+				routes:     make(map[string]*BaseRoute),
+				registered: make(map[string]string),
+			}
+			t.paths[key] = entry
+		}
+
+		entry.handlers[route.Method] = handlers[i]
+		entry.routes[route.Method] = route
+		if route.Method == http.MethodGet {
+			entry.noHead = route.eventStream
+		}
+
+		required := []string{route.Method, http.MethodOptions}
+		if route.Method == http.MethodGet {
+			required = append(required, http.MethodHead)
+		}
+		for _, method := range required {
+			if _, ok := entry.registered[method]; !ok {
+				entry.registered[method] = route.Path
+				methods[i] = append(methods[i], method)
+			}
+		}
+	}
+
+	return methods, nil
+}
+
+// lookup returns the handler for the method on the path, or nil if there is
+// none, along with the methods allowed on the path. When the method is
+// registered with the adapter for a path whose parameter names differ from
+// those of the handler's route, that path is returned as well.
+func (t *routeTable) lookup(key, method string) (http.Handler, string, []string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	routes := t.paths[key]
+	handler, route := routes.handlers[method], routes.routes[method]
+
+	allowed := make([]string, 0, len(routes.handlers)+2)
+	for m := range routes.handlers {
+		allowed = append(allowed, m)
+	}
+	if get := routes.handlers[http.MethodGet]; get != nil && !routes.noHead {
+		if handler == nil && method == http.MethodHead {
+			handler, route = headHandler(get), routes.routes[http.MethodGet]
+		}
+		allowed = append(allowed, http.MethodHead)
+	}
+	allowed = append(allowed, http.MethodOptions)
+	slices.Sort(allowed)
+
+	var adapterPath string
+	if route != nil && routes.registered[method] != route.Path {
+		adapterPath = routes.registered[method]
+	}
+
+	return handler, adapterPath, slices.Compact(allowed)
+}
+
+// preflight returns the CORS configuration of the route for the requested
+// method on the path if it allows the origin, or nil, along with the methods
+// whose routes allow the origin.
+func (t *routeTable) preflight(key, requestMethod, origin string) (*CORS, []string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	routes := t.paths[key]
+	corsFor := func(method string) *CORS {
+		if method == http.MethodHead && routes.handlers[http.MethodHead] == nil && !routes.noHead {
+			method = http.MethodGet
+		}
+		if routes.handlers[method] == nil {
+			return nil
+		}
+
+		return routes.routes[method].CORS
+	}
+
+	cors := corsFor(requestMethod)
+	if !cors.allowsOrigin(origin) {
+		return nil, nil
+	}
+
+	methods := []string{http.MethodHead}
+	for method := range routes.handlers {
+		methods = append(methods, method)
+	}
+	methods = slices.DeleteFunc(methods, func(method string) bool {
+		return !corsFor(method).allowsOrigin(origin)
+	})
+	slices.Sort(methods)
+
+	return cors, slices.Compact(methods)
+}
+
+// dispatch returns the handler registered with the adapter for the method on
+// the path. CORS preflight requests are answered for routes with CORS enabled.
+// Otherwise it runs the operation registered for the method. Without one, HEAD
+// runs the GET operation without sending its body, OPTIONS responds with the
+// allowed methods and any other method is rejected with 405 Method Not Allowed.
+func (a *api) dispatch(key, method string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if method == http.MethodOptions && isPreflight(r) && a.writePreflight(w, r, key) {
+			return
+		}
+
+		handler, adapterPath, allowed := a.routes.lookup(key, method)
+		if handler != nil {
+			if adapterPath != "" {
+				r = r.WithContext(context.WithValue(r.Context(), adapterPathKey, adapterPath))
+			}
+			handler.ServeHTTP(w, r)
+
+			return
+		}
+
+		if method == http.MethodOptions {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		a.writeMethodNotAllowed(w, r, allowed)
+	}
+}
+
+// methodNotAllowed responds to a request whose method is not registered for
+// its path with 405 Method Not Allowed and the methods allowed on the path.
+// It handles the requests that the router of a MethodNotAllowedAdapter
+// rejects before they reach dispatch.
+func (a *api) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	a.writeMethodNotAllowed(w, r, a.routes.allowedMethods(r.URL.Path))
+}
+
+// writeMethodNotAllowed writes a 405 Method Not Allowed error listing the
+// allowed methods in the Allow header, which is omitted if there are none.
+func (a *api) writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed []string) {
+	if len(allowed) == 0 {
+		WriteErr(a, r, w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	WriteErr(a, r, w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed, allowed methods: %s", r.Method, strings.Join(allowed, ", ")))
+}
+
+// allowedMethods returns the methods allowed on the registered paths that
+// match the request path, or nil if none does.
+func (t *routeTable) allowedMethods(requestPath string) []string {
+	t.mu.RLock()
+	var keys []string
+	for key, routes := range t.paths {
+		for _, route := range routes.routes {
+			if matchPath(route.Path, requestPath) {
+				keys = append(keys, key)
+			}
+
+			break
+		}
+	}
+	t.mu.RUnlock()
+
+	var allowed []string
+	for _, key := range keys {
+		_, _, methods := t.lookup(key, "")
+		allowed = append(allowed, methods...)
+	}
+	slices.Sort(allowed)
+
+	return slices.Compact(allowed)
+}
+
+// matchPath reports whether the request path matches the route path. A
+// parameter matches one segment, and a trailing wildcard ({name...} or *)
+// matches the rest of the path.
+func matchPath(routePath, requestPath string) bool {
+	routeSegments := strings.Split(strings.Trim(routePath, "/"), "/")
+	segments := strings.Split(strings.Trim(requestPath, "/"), "/")
+
+	for i, routeSegment := range routeSegments {
+		if routeSegment == "*" || strings.HasPrefix(routeSegment, "{") && strings.HasSuffix(routeSegment, "...}") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if strings.HasPrefix(routeSegment, "{") && strings.HasSuffix(routeSegment, "}") {
+			if segments[i] == "" {
+				return false
+			}
+
+			continue
+		}
+		if routeSegment != segments[i] {
+			return false
+		}
+	}
+
+	return len(segments) == len(routeSegments)
+}
+
+// headHandler runs a GET handler for a HEAD request, sending its status and
+// headers but discarding the body.
+func headHandler(get http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		get.ServeHTTP(&headResponseWriter{ResponseWriter: w}, r)
+	})
+}
+
+// headResponseWriter discards the response body written for a HEAD request.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *headResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// methodPlaceholderPrefix prefixes the placeholder paths of operations whose
+// method the generator does not support. They are moved to the route's path
+// item when the spec is generated.
+const methodPlaceholderPrefix = "/__zorya/methods/"
+
+// methodPlaceholderPath returns the placeholder path of an operation with an
+// unsupported method.
+func methodPlaceholderPath(method, path string) string {
+	return methodPlaceholderPrefix + method + path
+}
+
+// moveMethodOperation moves the operation generated at the placeholder path for
+// a route with an unsupported method to the route's path item, along with its
+// key order in pathsOrder: TRACE to the trace field, and QUERY and custom
+// methods to the extensions named after the fields OpenAPI 3.2 has for them
+// (see openapidoc.QueryField and openapidoc.AdditionalOperationsField).
+func moveMethodOperation(paths map[string]any, pathsOrder *jsonKeyOrder, route *BaseRoute) {
+	placeholder := methodPlaceholderPath(route.Method, route.Path)
+	placeholderItem, _ := paths[placeholder].(map[string]any)
+	operation, _ := placeholderItem["get"].(map[string]any)
+	if operation == nil {
+		return
+	}
+	delete(paths, placeholder)
+
+	operationOrder := pathsOrder.field(placeholder).field("get")
+	pathItemOrder := pathsOrder.field(route.Path)
+	if pathItemOrder == nil {
+		pathItemOrder = &jsonKeyOrder{}
+		if pathsOrder != nil {
+			pathsOrder.set(route.Path, pathItemOrder)
+		}
+	}
+
+	if id, _ := operation["operationId"].(string); strings.Contains(id, "__zorya") {
+		delete(operation, "operationId")
+	}
+
+	pathItem, _ := paths[route.Path].(map[string]any)
+	if pathItem == nil {
+		pathItem = map[string]any{}
+		paths[route.Path] = pathItem
+	}
+
+	if field, ok := openapidoc.PathItemField(route.Method); ok {
+		pathItem[field] = operation
+		pathItemOrder.set(field, operationOrder)
+
+		return
+	}
+
+	additional, _ := pathItem[openapidoc.AdditionalOperationsField].(map[string]any)
+	if additional == nil {
+		additional = map[string]any{}
+		pathItem[openapidoc.AdditionalOperationsField] = additional
+	}
+	additional[route.Method] = operation
+	additionalOrder := pathItemOrder.field(openapidoc.AdditionalOperationsField)
+	if additionalOrder == nil {
+		additionalOrder = &jsonKeyOrder{}
+		pathItemOrder.set(openapidoc.AdditionalOperationsField, additionalOrder)
+	}
+	additionalOrder.set(route.Method, operationOrder)
+}
@@ -0,0 +1,176 @@
+package zorya
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		route   string
+		request string
+		want    bool
+	}{
+		{route: "/items", request: "/items", want: true},
+		{route: "/items", request: "/items/", want: true},
+		{route: "/items", request: "/users", want: false},
+		{route: "/items/{id}", request: "/items/1", want: true},
+		{route: "/items/{id}", request: "/items", want: false},
+		{route: "/items/{id}", request: "/items/1/tags", want: false},
+		{route: "/items/{id}/tags", request: "/items/1/tags", want: true},
+		{route: "/files/{path...}", request: "/files/a/b/c", want: true},
+		{route: "/files/*", request: "/files/a/b", want: true},
+		{route: "/", request: "/", want: true},
+		{route: "/", request: "/items", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.route+" "+tt.request, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchPath(tt.route, tt.request))
+		})
+	}
+}
+
+func TestMethodHandling(t *testing.T) {
+	type ItemOutput struct {
+		ETag string `schema:"ETag,location=header"`
+		Body struct {
+			ID int `json:"id"`
+		} `body:"structured"`
+	}
+	type SearchInput struct {
+		Body struct {
+			Query string `json:"query"`
+		} `body:"structured"`
+	}
+
+	chi.RegisterMethod("QUERY")
+	chi.RegisterMethod("PURGE")
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter)
+
+	calls := 0
+	Get(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*ItemOutput, error) {
+		calls++
+		out := &ItemOutput{ETag: `"v1"`}
+		out.Body.ID = 1
+
+		return out, nil
+	})
+	Post(api, "/items/{id}", func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	Options(api, "/custom", func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	}, func(r *BaseRoute) {
+		r.DefaultStatus = http.StatusAccepted
+	})
+
+	search := func(ctx context.Context, _ *SearchInput) (*struct{}, error) {
+		return &struct{}{}, nil
+	}
+	for _, method := range []string{http.MethodTrace, "QUERY", "PURGE"} {
+		require.NoError(t, Register(api, BaseRoute{Method: method, Path: "/search"}, search))
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	// HEAD runs the GET handler without sending the body
+	recorder := serve(http.MethodHead, "/items/1")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"v1"`, recorder.Header().Get("ETag"))
+	assert.Empty(t, recorder.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// OPTIONS lists the allowed methods
+	recorder = serve(http.MethodOptions, "/items/1")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", recorder.Header().Get("Allow"))
+
+	// Explicit OPTIONS handlers take precedence
+	assert.Equal(t, http.StatusAccepted, serve(http.MethodOptions, "/custom").Code)
+
+	// Other methods are not registered with the router, which rejects them
+	// with 405 and the allowed methods
+	recorder = serve(http.MethodDelete, "/items/1")
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.ElementsMatch(t, []string{"GET", "HEAD", "OPTIONS", "POST"}, recorder.Header().Values("Allow"))
+
+	// Routes on the same path read their own parameter names
+	type ItemInput struct {
+		ItemID string `schema:"itemId,location=path,required=true"`
+	}
+	type ItemIDOutput struct {
+		Body struct {
+			ItemID string `json:"itemId"`
+		} `body:"structured"`
+	}
+	Put(api, "/items/{itemId}", func(ctx context.Context, input *ItemInput) (*ItemIDOutput, error) {
+		out := &ItemIDOutput{}
+		out.Body.ItemID = input.ItemID
+
+		return out, nil
+	})
+	recorder = serve(http.MethodPut, "/items/7")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"itemId": "7"}`, recorder.Body.String())
+
+	// Also when the method was registered with the router for another route
+	type ItemIDHeader struct {
+		ItemID string `schema:"X-Item-ID,location=header"`
+	}
+	Head(api, "/items/{itemId}", func(ctx context.Context, input *ItemInput) (*ItemIDHeader, error) {
+		return &ItemIDHeader{ItemID: input.ItemID}, nil
+	})
+	recorder = serve(http.MethodHead, "/items/8")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "8", recorder.Header().Get("X-Item-ID"))
+
+	// Registering a method twice on a path fails
+	err := Register(api, BaseRoute{Method: http.MethodPost, Path: "/items/{name}"}, func(ctx context.Context, _ *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+	require.EqualError(t, err, "route POST /items/{name} conflicts with POST /items/{id}")
+
+	// Custom methods are served
+	req := httptest.NewRequest("QUERY", "/search", strings.NewReader(`{"query": "items"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "OPTIONS, PURGE, QUERY, TRACE", serve(http.MethodOptions, "/search").Header().Get("Allow"))
+
+	// TRACE, QUERY and custom methods are documented in their path item
+	recorder = serve(http.MethodGet, "/openapi.json")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	assert.Equal(t, "3.1.2", spec["openapi"], "the generated version is kept")
+
+	paths := spec["paths"].(map[string]any)
+	searchItem := paths["/search"].(map[string]any)
+	assert.Contains(t, searchItem, "trace")
+	assert.Contains(t, searchItem, "x-query")
+	assert.Contains(t, searchItem["x-additionalOperations"], "PURGE")
+	assert.Contains(t, searchItem["x-query"], "requestBody")
+	for path := range paths {
+		assert.NotContains(t, path, "__zorya")
+	}
+}
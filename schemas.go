@@ -0,0 +1,188 @@
+package zorya
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/talav/zorya/internal/openapidoc"
+)
+
+const (
+	jsonSchemaDialect      = "https://json-schema.org/draft/2020-12/schema"
+	componentSchemasPrefix = "#/components/schemas/"
+)
+
+// registerSchemasEndpoint registers the endpoint serving each component schema
+// as a standalone JSON Schema document at {SchemasPath}/{Name}.json.
+func registerSchemasEndpoint(a *api) {
+	if a.config.SchemasPath == "" {
+		return
+	}
+
+	route := &BaseRoute{
+		Method: http.MethodGet,
+		Path:   a.config.SchemasPath + "/{schema}",
+	}
+
+	a.adapter.Handle(route, func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(a.adapter.ExtractRouterParams(r, route)["schema"], ".json")
+		if !ok {
+			WriteErr(a, r, w, http.StatusNotFound, "schema not found")
+			return
+		}
+
+		schemaJSON, err := a.openapiState.Schema(r.Context(), name)
+		if err != nil {
+			WriteErr(a, r, w, http.StatusInternalServerError, "failed to generate OpenAPI spec", err)
+			return
+		}
+		if schemaJSON == nil {
+			WriteErr(a, r, w, http.StatusNotFound, "schema not found")
+			return
+		}
+
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(schemaJSON))
+		w.Header().Set("ETag", etag)
+
+		if match := r.Header.Get("If-None-Match"); match == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(schemaJSON)
+	})
+}
+
+// schemaURL returns the URL of the standalone document for the named schema.
+func schemaURL(schemasPath, name string) string {
+	return schemasPath + "/" + name + ".json"
+}
+
+// describedByLink returns the Link header value pointing to the schema of the
+// route's response body, or an empty string if there is none or the spec has
+// not been generated yet. Requests never generate the spec.
+func (r *BaseRoute) describedByLink() string {
+	if r.describedBy == nil {
+		return ""
+	}
+	if link := r.describedBy.Load(); link != nil {
+		return *link
+	}
+
+	return ""
+}
+
+// buildSchemaDocuments converts the component schemas of the spec into
+// standalone JSON Schema 2020-12 documents, with references to other component
+// schemas rewritten to their sibling document URLs.
+func (s *openapiState) buildSchemaDocuments(doc map[string]any) (map[string][]byte, error) {
+	if s.schemasPath == "" {
+		return nil, nil
+	}
+
+	components, _ := doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+
+	result := make(map[string][]byte, len(schemas))
+	for name, schema := range schemas {
+		schemaDoc, _ := rewriteSchemaRefs(schema).(map[string]any)
+		if schemaDoc == nil {
+			continue
+		}
+		schemaDoc["$schema"] = jsonSchemaDialect
+		schemaDoc["$id"] = schemaURL(s.schemasPath, name)
+
+		data, err := encodeJSON(schemaDoc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schema %s: %w", name, err)
+		}
+		result[name] = data
+	}
+
+	return result, nil
+}
+
+// rewriteSchemaRefs returns a copy of v with every $ref to a component schema
+// replaced by a reference relative to the sibling document (e.g. "User.json").
+func rewriteSchemaRefs(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for k, item := range value {
+			if ref, ok := item.(string); ok && k == "$ref" && strings.HasPrefix(ref, componentSchemasPrefix) {
+				name, pointer, _ := strings.Cut(strings.TrimPrefix(ref, componentSchemasPrefix), "/")
+				if pointer != "" {
+					out[k] = name + ".json#/" + pointer
+				} else {
+					out[k] = name + ".json"
+				}
+
+				continue
+			}
+			out[k] = rewriteSchemaRefs(item)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[i] = rewriteSchemaRefs(item)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
+
+// setDescribedByLinks sets the Link header value of each route pointing to the
+// component schema referenced by its default response body in the generated
+// spec.
+func (s *openapiState) setDescribedByLinks(doc map[string]any) {
+	if s.schemasPath == "" {
+		return
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for _, route := range s.routes {
+		if route.describedBy == nil {
+			continue
+		}
+
+		status := route.DefaultStatus
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		pathItem, _ := paths[route.Path].(map[string]any)
+		operation := openapidoc.PathItemOperation(pathItem, route.Method)
+		responses, _ := operation["responses"].(map[string]any)
+		response, _ := responses[strconv.Itoa(status)].(map[string]any)
+		content, _ := response["content"].(map[string]any)
+
+		mediaTypes := make([]string, 0, len(content))
+		for mediaType := range content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Strings(mediaTypes)
+
+		var link *string
+		for _, mediaType := range mediaTypes {
+			media, _ := content[mediaType].(map[string]any)
+			schema, _ := media["schema"].(map[string]any)
+			if ref, ok := schema["$ref"].(string); ok && strings.HasPrefix(ref, componentSchemasPrefix) {
+				value := "<" + schemaURL(s.schemasPath, strings.TrimPrefix(ref, componentSchemasPrefix)) + `>; rel="describedby"`
+				link = &value
+
+				break
+			}
+		}
+		route.describedBy.Store(link)
+	}
+}
@@ -0,0 +1,70 @@
+package zorya
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemasEndpoint(t *testing.T) {
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter)
+
+	Get(api, "/users/{id}", func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		output := &GetUserOutput{}
+		output.Body.ID = input.ID
+
+		return output, nil
+	})
+
+	// Requests do not generate the spec, so the link is unknown until then
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Link"))
+
+	// Standalone schema with sibling references
+	req = httptest.NewRequest(http.MethodGet, "/schemas/ErrorModel.json", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/schema+json", recorder.Header().Get("Content-Type"))
+	assert.NotEmpty(t, recorder.Header().Get("ETag"))
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &schema))
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Equal(t, "/schemas/ErrorModel.json", schema["$id"])
+	assert.JSONEq(t, `{"$ref": "ErrorDetail.json"}`, mustMarshal(t, schema["properties"].(map[string]any)["errors"].(map[string]any)["items"]))
+
+	// Unknown schema
+	req = httptest.NewRequest(http.MethodGet, "/schemas/Unknown.json", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// Responses link to the schema of their body
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `</schemas/GetUserOutputBody.json>; rel="describedby"`, recorder.Header().Get("Link"))
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return string(data)
+}
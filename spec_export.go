@@ -0,0 +1,27 @@
+package zorya
+
+import "context"
+
+// SpecOptions selects the encoding of a spec returned by ExportSpec.
+type SpecOptions struct {
+	// YAML encodes the spec as YAML instead of JSON.
+	YAML bool
+
+	// OpenAPI30 downgrades the spec to OpenAPI 3.0.3, like the spec served at
+	// Config.OpenAPI30Path.
+	OpenAPI30 bool
+}
+
+// ExportSpec returns the OpenAPI spec of the registered operations, as served
+// on the spec endpoints, without starting a server. Use it to write the spec
+// to disk at build time, e.g. with the zoryacli package.
+//
+// Example:
+//
+//	api := zorya.NewAPI(adapters.NewStdlib(http.NewServeMux()))
+//	registerRoutes(api)
+//
+//	spec, err := zorya.ExportSpec(context.Background(), api, zorya.SpecOptions{YAML: true})
+func ExportSpec(ctx context.Context, api API, opts SpecOptions) ([]byte, error) {
+	return api.encodedSpec(ctx, specFormat{yaml: opts.YAML, openAPI30: opts.OpenAPI30})
+}
@@ -0,0 +1,523 @@
+// Package specdiff compares two OpenAPI specs and classifies the changes that
+// break existing clients: removed operations and responses, newly required
+// parameters and request fields, narrowed enums, and removed or no longer
+// required response fields.
+//
+// Usage:
+//
+//	report, err := specdiff.Diff(baseSpec, revisionSpec)
+//	if err != nil {
+//		return err
+//	}
+//
+//	// Fails if there are breaking changes and info.version was not bumped
+//	if err := report.Err(); err != nil {
+//		return err
+//	}
+package specdiff
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/talav/zorya/internal/openapidoc"
+	"gopkg.in/yaml.v3"
+)
+
+// pathParamPattern matches path parameters, whose names do not matter when
+// matching paths.
+var pathParamPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// Change is a difference between two specs.
+type Change struct {
+	// Breaking reports whether the change can break existing clients.
+	Breaking bool
+
+	// Operation is the affected operation, e.g. "GET /users/{id}", or empty
+	// for changes to the document itself.
+	Operation string
+
+	// Message describes the change.
+	Message string
+}
+
+// String returns the change as "[BREAKING] OPERATION: message".
+func (c Change) String() string {
+	s := c.Message
+	if c.Operation != "" {
+		s = c.Operation + ": " + s
+	}
+	if c.Breaking {
+		s = "[BREAKING] " + s
+	}
+
+	return s
+}
+
+// Report lists the changes from a base spec to a revision.
+type Report struct {
+	// BaseVersion and RevisionVersion are the info.version of the specs.
+	BaseVersion     string
+	RevisionVersion string
+
+	// Changes are sorted by operation, breaking changes first, then by
+	// message.
+	Changes []Change
+}
+
+// Breaking returns the breaking changes.
+func (r *Report) Breaking() []Change {
+	return slices.DeleteFunc(slices.Clone(r.Changes), func(c Change) bool { return !c.Breaking })
+}
+
+// VersionBumped reports whether info.version differs between the specs.
+func (r *Report) VersionBumped() bool {
+	return r.BaseVersion != r.RevisionVersion
+}
+
+// Err returns an error listing the breaking changes if there are any and the
+// version was not bumped, nil otherwise.
+func (r *Report) Err() error {
+	breaking := r.Breaking()
+	if len(breaking) == 0 || r.VersionBumped() {
+		return nil
+	}
+
+	lines := make([]string, 0, len(breaking))
+	for _, c := range breaking {
+		lines = append(lines, c.String())
+	}
+
+	return fmt.Errorf("%d breaking changes without a version bump (info.version is still %q):\n%s",
+		len(breaking), r.RevisionVersion, strings.Join(lines, "\n"))
+}
+
+// Diff compares two OpenAPI specs, encoded as JSON or YAML.
+func Diff(base, revision []byte) (*Report, error) {
+	baseDoc, err := decode(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base spec: %w", err)
+	}
+	revisionDoc, err := decode(revision)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision spec: %w", err)
+	}
+
+	d := &differ{base: baseDoc, revision: revisionDoc}
+	d.diffOperations()
+
+	sort.Slice(d.changes, func(i, j int) bool {
+		if d.changes[i].Operation != d.changes[j].Operation {
+			return d.changes[i].Operation < d.changes[j].Operation
+		}
+
+		if d.changes[i].Breaking != d.changes[j].Breaking {
+			return d.changes[i].Breaking
+		}
+
+		return d.changes[i].Message < d.changes[j].Message
+	})
+
+	return &Report{
+		BaseVersion:     infoVersion(baseDoc),
+		RevisionVersion: infoVersion(revisionDoc),
+		Changes:         d.changes,
+	}, nil
+}
+
+// decode decodes a JSON or YAML document, JSON being a subset of YAML.
+func decode(data []byte) (map[string]any, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, errors.New("empty document")
+	}
+	if _, ok := doc["openapi"]; !ok {
+		return nil, errors.New("missing openapi version field")
+	}
+
+	return doc, nil
+}
+
+func infoVersion(doc map[string]any) string {
+	info, _ := doc["info"].(map[string]any)
+	version, _ := info["version"].(string)
+
+	return version
+}
+
+// direction tells whether a schema describes data sent by clients or received
+// by them, which decides what breaks them.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+// differ accumulates the changes between two documents.
+type differ struct {
+	base, revision map[string]any
+	changes        []Change
+}
+
+func (d *differ) add(breaking bool, operation, format string, args ...any) {
+	d.changes = append(d.changes, Change{Breaking: breaking, Operation: operation, Message: fmt.Sprintf(format, args...)})
+}
+
+// operation is an operation of a document with the parameters of its path item.
+type operation struct {
+	name       string // e.g. "GET /users/{id}"
+	path       string
+	object     map[string]any
+	parameters []any
+}
+
+// operations returns the operations of the document by method and path with
+// unnamed parameters.
+func operations(doc map[string]any) map[string]operation {
+	result := map[string]operation{}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for path, value := range paths {
+		pathItem, _ := value.(map[string]any)
+		params, _ := pathItem["parameters"].([]any)
+		key := pathParamPattern.ReplaceAllString(path, "{}")
+
+		for _, method := range openapidoc.Methods(pathItem) {
+			op := openapidoc.PathItemOperation(pathItem, method)
+			method = strings.ToUpper(method)
+			result[method+" "+key] = operation{name: method + " " + path, path: path, object: op, parameters: params}
+		}
+	}
+
+	return result
+}
+
+func (d *differ) diffOperations() {
+	baseOps := operations(d.base)
+	revisionOps := operations(d.revision)
+
+	for key, baseOp := range baseOps {
+		revisionOp, ok := revisionOps[key]
+		if !ok {
+			d.add(true, baseOp.name, "operation removed")
+			continue
+		}
+		d.diffParameters(revisionOp.name, baseOp, revisionOp)
+		d.diffRequestBody(revisionOp.name, baseOp.object, revisionOp.object)
+		d.diffResponses(revisionOp.name, baseOp.object, revisionOp.object)
+	}
+
+	for key, revisionOp := range revisionOps {
+		if _, ok := baseOps[key]; !ok {
+			d.add(false, revisionOp.name, "operation added")
+		}
+	}
+}
+
+// parameters returns the parameters of the operation by location and name,
+// operation parameters overriding path item ones. Path parameters are keyed
+// by their position in the path, as renaming them does not affect clients.
+func parameters(doc map[string]any, op operation) map[string]map[string]any {
+	positions := map[string]int{}
+	for i, segment := range pathParamPattern.FindAllString(op.path, -1) {
+		positions[strings.Trim(segment, "{}")] = i
+	}
+
+	result := map[string]map[string]any{}
+	for _, list := range [][]any{op.parameters, asSlice(op.object["parameters"])} {
+		for _, value := range list {
+			param := resolve(doc, value)
+			in, _ := param["in"].(string)
+			name, _ := param["name"].(string)
+			switch in {
+			case "header":
+				name = strings.ToLower(name)
+			case "path":
+				name = fmt.Sprint(positions[name])
+			default:
+			}
+			result[in+" "+name] = param
+		}
+	}
+
+	return result
+}
+
+func (d *differ) diffParameters(name string, baseOp, revisionOp operation) {
+	baseParams := parameters(d.base, baseOp)
+	revisionParams := parameters(d.revision, revisionOp)
+
+	for key, param := range revisionParams {
+		label := fmt.Sprintf("%s parameter %q", param["in"], param["name"])
+		baseParam, existed := baseParams[key]
+		switch {
+		case !existed && isTrue(param["required"]):
+			d.add(true, name, "required %s added", label)
+		case !existed:
+			d.add(false, name, "optional %s added", label)
+		case isTrue(param["required"]) && !isTrue(baseParam["required"]):
+			d.add(true, name, "%s is now required", label)
+		default:
+		}
+
+		if existed {
+			baseSchema, _ := baseParam["schema"].(map[string]any)
+			revisionSchema, _ := param["schema"].(map[string]any)
+			d.diffSchema(name, label, request, baseSchema, revisionSchema, map[[2]string]bool{})
+		}
+	}
+
+	for key, param := range baseParams {
+		if _, ok := revisionParams[key]; !ok {
+			d.add(false, name, "%s parameter %q removed", param["in"], param["name"])
+		}
+	}
+}
+
+func (d *differ) diffRequestBody(name string, baseOp, revisionOp map[string]any) {
+	baseBody := resolve(d.base, baseOp["requestBody"])
+	revisionBody := resolve(d.revision, revisionOp["requestBody"])
+	if revisionBody == nil {
+		return
+	}
+	if isTrue(revisionBody["required"]) && !isTrue(baseBody["required"]) {
+		d.add(true, name, "request body is now required")
+	}
+
+	baseContent, _ := baseBody["content"].(map[string]any)
+	revisionContent, _ := revisionBody["content"].(map[string]any)
+	for mediaType, value := range baseContent {
+		revisionMedia, ok := revisionContent[mediaType].(map[string]any)
+		if !ok {
+			d.add(true, name, "request body media type %s removed", mediaType)
+			continue
+		}
+		baseMedia, _ := value.(map[string]any)
+		baseSchema, _ := baseMedia["schema"].(map[string]any)
+		revisionSchema, _ := revisionMedia["schema"].(map[string]any)
+		d.diffSchema(name, "request body", request, baseSchema, revisionSchema, map[[2]string]bool{})
+	}
+}
+
+func (d *differ) diffResponses(name string, baseOp, revisionOp map[string]any) {
+	baseResponses, _ := baseOp["responses"].(map[string]any)
+	revisionResponses, _ := revisionOp["responses"].(map[string]any)
+
+	for status, value := range baseResponses {
+		baseResponse := resolve(d.base, value)
+		revisionValue, ok := revisionResponses[status]
+		if !ok {
+			if strings.HasPrefix(status, "2") {
+				d.add(true, name, "response %s removed", status)
+			}
+			continue
+		}
+		revisionResponse := resolve(d.revision, revisionValue)
+
+		baseContent, _ := baseResponse["content"].(map[string]any)
+		revisionContent, _ := revisionResponse["content"].(map[string]any)
+		for mediaType, value := range baseContent {
+			revisionMedia, ok := revisionContent[mediaType].(map[string]any)
+			if !ok {
+				d.add(true, name, "response %s media type %s removed", status, mediaType)
+				continue
+			}
+			baseMedia, _ := value.(map[string]any)
+			baseSchema, _ := baseMedia["schema"].(map[string]any)
+			revisionSchema, _ := revisionMedia["schema"].(map[string]any)
+			d.diffSchema(name, "response "+status, response, baseSchema, revisionSchema, map[[2]string]bool{})
+		}
+	}
+}
+
+// diffSchema compares the schemas of a parameter, request body or response
+// body. Schemas compared through the same references are compared once, which
+// stops the recursion for recursive schemas.
+func (d *differ) diffSchema(name, label string, dir direction, base, revision map[string]any, seen map[[2]string]bool) {
+	if base == nil || revision == nil {
+		return
+	}
+
+	baseRef, _ := base["$ref"].(string)
+	revisionRef, _ := revision["$ref"].(string)
+	if baseRef != "" || revisionRef != "" {
+		if seen[[2]string{baseRef, revisionRef}] {
+			return
+		}
+		seen[[2]string{baseRef, revisionRef}] = true
+	}
+	base = flatten(d.base, base)
+	revision = flatten(d.revision, revision)
+
+	baseType, _ := base["type"].(string)
+	revisionType, _ := revision["type"].(string)
+	if baseType != "" && revisionType != "" && baseType != revisionType {
+		d.add(true, name, "%s type changed from %s to %s", label, baseType, revisionType)
+		return
+	}
+
+	d.diffEnum(name, label, dir, base, revision)
+
+	baseProps, _ := base["properties"].(map[string]any)
+	revisionProps, _ := revision["properties"].(map[string]any)
+	baseRequired := asSlice(base["required"])
+	revisionRequired := asSlice(revision["required"])
+
+	for prop, value := range baseProps {
+		field := fmt.Sprintf("%s field %q", label, prop)
+		revisionValue, ok := revisionProps[prop]
+		if !ok {
+			if dir == response {
+				d.add(true, name, "%s removed", field)
+			} else {
+				d.add(false, name, "%s removed", field)
+			}
+			continue
+		}
+
+		if dir == response && contains(baseRequired, prop) && !contains(revisionRequired, prop) {
+			d.add(true, name, "%s is no longer required", field)
+		}
+
+		baseProp, _ := value.(map[string]any)
+		revisionProp, _ := revisionValue.(map[string]any)
+		d.diffSchema(name, field, dir, baseProp, revisionProp, seen)
+	}
+
+	if dir == request {
+		for _, value := range revisionRequired {
+			prop, _ := value.(string)
+			if !contains(baseRequired, value) {
+				d.add(true, name, "%s field %q is now required", label, prop)
+			}
+		}
+	}
+
+	baseItems, _ := base["items"].(map[string]any)
+	revisionItems, _ := revision["items"].(map[string]any)
+	d.diffSchema(name, label+" items", dir, baseItems, revisionItems, seen)
+}
+
+// diffEnum reports enum values removed from request schemas (or an enum added
+// to them), which clients may still send, and values added to response schemas,
+// which clients may not handle.
+func (d *differ) diffEnum(name, label string, dir direction, base, revision map[string]any) {
+	baseEnum, baseHasEnum := base["enum"].([]any)
+	revisionEnum, revisionHasEnum := revision["enum"].([]any)
+	if !revisionHasEnum {
+		return
+	}
+
+	if dir == request {
+		if !baseHasEnum {
+			d.add(true, name, "%s is now restricted to %v", label, revisionEnum)
+			return
+		}
+		for _, value := range baseEnum {
+			if !contains(revisionEnum, value) {
+				d.add(true, name, "%s enum value %v removed", label, value)
+			}
+		}
+
+		return
+	}
+
+	for _, value := range revisionEnum {
+		if baseHasEnum && !contains(baseEnum, value) {
+			d.add(false, name, "%s enum value %v added", label, value)
+		}
+	}
+}
+
+// flatten resolves a schema reference and merges the keywords, properties and
+// required fields of its allOf members into it.
+func flatten(doc map[string]any, schema map[string]any) map[string]any {
+	schema = resolve(doc, schema)
+	allOf := asSlice(schema["allOf"])
+	if len(allOf) == 0 {
+		return schema
+	}
+
+	result := maps.Clone(schema)
+	delete(result, "allOf")
+	properties, _ := schema["properties"].(map[string]any)
+	properties = maps.Clone(properties)
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	required := slices.Clone(asSlice(schema["required"]))
+
+	for _, value := range allOf {
+		member := resolve(doc, value)
+		if member == nil {
+			continue
+		}
+		member = flatten(doc, member)
+		for key, value := range member {
+			if _, ok := result[key]; !ok {
+				result[key] = value
+			}
+		}
+		props, _ := member["properties"].(map[string]any)
+		maps.Copy(properties, props)
+		required = append(required, asSlice(member["required"])...)
+	}
+	result["properties"] = properties
+	result["required"] = required
+
+	return result
+}
+
+// resolve follows local references of a spec object, returning nil if v is not
+// an object or the reference cannot be resolved.
+func resolve(doc map[string]any, v any) map[string]any {
+	for range 32 {
+		object, _ := v.(map[string]any)
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object
+		}
+
+		pointer, ok := strings.CutPrefix(ref, "#/")
+		if !ok {
+			return nil
+		}
+		var current any = doc
+		for token := range strings.SplitSeq(pointer, "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			m, _ := current.(map[string]any)
+			current = m[token]
+		}
+		v = current
+	}
+
+	// Reference cycle
+	return nil
+}
+
+// contains reports whether the list has a value deeply equal to v.
+func contains(list []any, v any) bool {
+	return slices.ContainsFunc(list, func(item any) bool { return reflect.DeepEqual(item, v) })
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+
+	return s
+}
+
+func isTrue(v any) bool {
+	b, _ := v.(bool)
+
+	return b
+}
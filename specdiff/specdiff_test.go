@@ -0,0 +1,170 @@
+package specdiff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/talav/zorya/specdiff"
+)
+
+const baseSpec = `{
+  "openapi": "3.1.2",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "get": {
+        "parameters": [
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}},
+          {"name": "status", "in": "query", "schema": {"type": "string", "enum": ["available", "sold", "pending"]}}
+        ],
+        "responses": {
+          "200": {"description": "OK", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}
+        }
+      },
+      "post": {
+        "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewPet"}}}},
+        "responses": {"201": {"description": "Created"}}
+      }
+    },
+    "/pets/{id}": {
+      "get": {
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+      },
+      "delete": {
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {"204": {"description": "No Content"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["id", "name"],
+        "properties": {
+          "id": {"type": "integer"},
+          "name": {"type": "string"},
+          "tag": {"type": "string"},
+          "parent": {"$ref": "#/components/schemas/Pet"}
+        }
+      },
+      "NewPet": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {"name": {"type": "string"}, "tag": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+// revisionSpec is baseSpec with breaking and non-breaking changes, in YAML.
+const revisionSpec = `
+openapi: 3.1.2
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: integer}}
+        - {name: status, in: query, schema: {type: string, enum: [available, sold]}}
+        - {name: sort, in: query, schema: {type: string}}
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/Pet"}}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/NewPet"}
+      responses:
+        "201": {description: Created}
+  /pets/{petId}:
+    get:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+  /owners:
+    get:
+      responses:
+        "200": {description: OK}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+        parent: {$ref: "#/components/schemas/Pet"}
+    NewPet:
+      allOf:
+        - type: object
+          required: [name]
+          properties: {name: {type: string}}
+        - type: object
+          required: [tag]
+          properties: {tag: {type: string}}
+`
+
+func TestDiff(t *testing.T) {
+	report, err := specdiff.Diff([]byte(baseSpec), []byte(revisionSpec))
+	require.NoError(t, err)
+
+	var changes []string
+	for _, change := range report.Changes {
+		changes = append(changes, change.String())
+	}
+	assert.Equal(t, []string{
+		"[BREAKING] DELETE /pets/{id}: operation removed",
+		"GET /owners: operation added",
+		`[BREAKING] GET /pets: query parameter "limit" is now required`,
+		`[BREAKING] GET /pets: query parameter "status" enum value pending removed`,
+		`[BREAKING] GET /pets: response 200 items field "name" is no longer required`,
+		`[BREAKING] GET /pets: response 200 items field "tag" removed`,
+		`GET /pets: optional query parameter "sort" added`,
+		`[BREAKING] GET /pets/{petId}: response 200 field "name" is no longer required`,
+		`[BREAKING] GET /pets/{petId}: response 200 field "tag" removed`,
+		`[BREAKING] POST /pets: request body field "tag" is now required`,
+	}, changes)
+
+	assert.Len(t, report.Breaking(), 8)
+	assert.False(t, report.VersionBumped())
+	require.Error(t, report.Err())
+	assert.Contains(t, report.Err().Error(), "8 breaking changes without a version bump")
+}
+
+func TestDiff_VersionBump(t *testing.T) {
+	revision := []byte(`{"openapi": "3.1.2", "info": {"title": "Pets", "version": "2.0.0"}, "paths": {}}`)
+
+	report, err := specdiff.Diff([]byte(baseSpec), revision)
+	require.NoError(t, err)
+
+	assert.Len(t, report.Breaking(), 4, "every operation is removed")
+	assert.True(t, report.VersionBumped())
+	assert.NoError(t, report.Err(), "breaking changes are allowed with a version bump")
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	report, err := specdiff.Diff([]byte(baseSpec), []byte(baseSpec))
+	require.NoError(t, err)
+
+	assert.Empty(t, report.Changes)
+	assert.NoError(t, report.Err())
+}
+
+func TestDiff_InvalidSpec(t *testing.T) {
+	_, err := specdiff.Diff([]byte(baseSpec), []byte(`{"info": {}}`))
+	assert.ErrorContains(t, err, "invalid revision spec")
+}
@@ -0,0 +1,451 @@
+package zorya
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/talav/openapi"
+)
+
+// DefaultHeartbeatInterval is the default interval of Server-Sent Events heartbeats (15 seconds).
+const DefaultHeartbeatInterval = 15 * time.Second
+
+const contentTypeEventStream = "text/event-stream"
+
+var eventStreamType = reflect.TypeFor[EventStream]()
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is the event ID, sent back by the client in the Last-Event-ID header
+	// when it reconnects. It must not contain CR, LF or NUL characters.
+	ID string
+
+	// Name is the event type. If empty, it is looked up from the type of Data
+	// in BaseRoute.Events. Events without a name are dispatched as "message"
+	// by clients. It must not contain CR, LF or NUL characters.
+	Name string
+
+	// Data is the event payload, marshaled with the format negotiated from the
+	// Accept header (JSON by default).
+	Data any
+
+	// Retry tells the client how long to wait before reconnecting. Zero omits it.
+	Retry time.Duration
+}
+
+// EventSender sends an event to the client. It returns an error once the
+// client has disconnected or the event could not be written.
+type EventSender func(Event) error
+
+// Data sends an event with the given payload.
+func (s EventSender) Data(data any) error {
+	return s(Event{Data: data})
+}
+
+// EventStream is a response body that streams Server-Sent Events. The function
+// runs after the response headers are sent and streams events until it
+// returns or ctx is canceled because the client disconnected. Heartbeat
+// comments keep idle connections open (see BaseRoute.HeartbeatInterval).
+// An error returned while the client is connected is sent as an "error" event
+// carrying the error model.
+//
+// Example:
+//
+//	type EventsOutput struct {
+//		Body zorya.EventStream
+//	}
+//
+//	zorya.Get(api, "/events", func(ctx context.Context, input *EventsInput) (*EventsOutput, error) {
+//		return &EventsOutput{Body: func(ctx context.Context, send zorya.EventSender) error {
+//			return send(zorya.Event{ID: "1", Data: UserCreated{ID: 1}})
+//		}}, nil
+//	}, zorya.Events(map[string]any{"userCreated": UserCreated{}}))
+type EventStream func(ctx context.Context, send EventSender) error
+
+// EventChannel returns an EventStream that sends the events received from ch
+// until it is closed or the client disconnects.
+func EventChannel(ch <-chan Event) EventStream {
+	return func(ctx context.Context, send EventSender) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case event, ok := <-ch:
+				if !ok {
+					return nil
+				}
+				if err := send(event); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// EventStreamParams holds the request headers sent by Server-Sent Events
+// clients. Embed it in the input struct to resume a stream after a reconnect.
+//
+// Example:
+//
+//	type EventsInput struct {
+//		zorya.EventStreamParams
+//	}
+type EventStreamParams struct {
+	LastEventID string `schema:"Last-Event-ID,location=header"`
+}
+
+// eventStreamInput is implemented by inputs embedding EventStreamParams. The
+// codec does not decode parameters of embedded structs, so the handler reads
+// them from the request itself.
+type eventStreamInput interface {
+	setEventStreamParams(r *http.Request)
+}
+
+func (p *EventStreamParams) setEventStreamParams(r *http.Request) {
+	p.LastEventID = r.Header.Get("Last-Event-ID")
+}
+
+// Events declares the Server-Sent Events of a route whose output Body is an
+// EventStream, mapping each event name to a sample value of its data type.
+// Events sent without a name are named after the type of their data, using the
+// first name in sorted order when several events share it. Each event is
+// documented with its data schema in the OpenAPI text/event-stream response.
+func Events(events map[string]any) func(*BaseRoute) {
+	return func(r *BaseRoute) {
+		r.Events = events
+	}
+}
+
+// hasEventStreamBody reports whether the output struct streams Server-Sent Events.
+func hasEventStreamBody(outputType reflect.Type) bool {
+	field, ok := outputType.FieldByName("Body")
+
+	return ok && field.Type == eventStreamType
+}
+
+// writeEventStream sends the response headers and runs the stream, writing
+// each event in the text/event-stream format along with periodic heartbeats.
+func writeEventStream(api API, r *http.Request, w http.ResponseWriter, route *BaseRoute, stream EventStream, status int) {
+	ct, err := api.Negotiate(eventDataAccept(r.Header.Get("Accept")))
+	var se StatusError
+	if errors.As(err, &se) {
+		WriteErr(api, r, w, 0, "", se)
+
+		return
+	}
+	if err != nil {
+		WriteErr(api, r, w, http.StatusNotAcceptable, "Not Acceptable", err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+
+	ew := &eventWriter{w: w, rc: http.NewResponseController(w)}
+	// Send the headers right away so the client knows the stream is open
+	_ = ew.write(nil)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	if interval := heartbeatInterval(route); interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ew.heartbeat(ctx, cancel, interval)
+		}()
+	}
+
+	send := func(event Event) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if event.Name == "" {
+			event.Name = eventName(route.Events, event.Data)
+		}
+
+		msg, err := encodeEvent(api, ct, event)
+		if err != nil {
+			api.reportMarshalError(r, ct, err)
+
+			return err
+		}
+		if err := ew.write(msg); err != nil {
+			cancel()
+
+			return err
+		}
+
+		return nil
+	}
+
+	if err := stream(ctx, send); err != nil && ctx.Err() == nil {
+		errModel, _ := processExistingError(err)
+		if msg, err := encodeEvent(api, ct, Event{Name: "error", Data: errModel}); err == nil {
+			_ = ew.write(msg)
+		}
+	}
+
+	cancel()
+	wg.Wait()
+}
+
+// eventWriter serializes writes of events and heartbeats, flushing each one.
+type eventWriter struct {
+	mu sync.Mutex
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (ew *eventWriter) write(b []byte) error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	if _, err := ew.w.Write(b); err != nil {
+		return err
+	}
+	if err := ew.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// heartbeat writes a comment line every interval until ctx is done. A failed
+// write means the client is gone and cancels the stream.
+func (ew *eventWriter) heartbeat(ctx context.Context, cancel context.CancelFunc, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ew.write([]byte(": heartbeat\n\n")); err != nil {
+				cancel()
+
+				return
+			}
+		}
+	}
+}
+
+// heartbeatInterval returns the route's heartbeat interval, or 0 if disabled.
+func heartbeatInterval(route *BaseRoute) time.Duration {
+	switch {
+	case route.HeartbeatInterval == 0:
+		return DefaultHeartbeatInterval
+	case route.HeartbeatInterval < 0:
+		return 0
+	default:
+		return route.HeartbeatInterval
+	}
+}
+
+// encodeEvent encodes an event in the text/event-stream format, marshaling its
+// data with the format for the content type.
+func encodeEvent(api API, ct string, event Event) ([]byte, error) {
+	// A line break would end the field early and inject fields or events
+	// into the stream
+	if strings.ContainsAny(event.ID, "\r\n\x00") {
+		return nil, fmt.Errorf("invalid event ID %q: must not contain CR, LF or NUL", event.ID)
+	}
+	if strings.ContainsAny(event.Name, "\r\n\x00") {
+		return nil, fmt.Errorf("invalid event name %q: must not contain CR, LF or NUL", event.Name)
+	}
+
+	var data bytes.Buffer
+	if err := api.Marshal(&data, ct, event.Data); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if event.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", event.ID)
+	}
+	if event.Name != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event.Name)
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", event.Retry.Milliseconds())
+	}
+	// Clients end lines at CRLF, CR or LF, so each one starts a new data line
+	lines := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data.String())
+	for line := range strings.SplitSeq(strings.TrimSuffix(lines, "\n"), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+// eventName returns the name of the event whose sample in events has the same
+// type as data, or an empty string if there is none. When several events share
+// the type, the first name in sorted order is used.
+func eventName(events map[string]any, data any) string {
+	typ := derefType(reflect.TypeOf(data))
+	if typ == nil {
+		return ""
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(events)) {
+		if derefType(reflect.TypeOf(events[name])) == typ {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// derefType returns the element type of pointer types.
+func derefType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ
+}
+
+// eventDataAccept removes text/event-stream from an Accept header, leaving the
+// media types acceptable for event data.
+func eventDataAccept(accept string) string {
+	parts := strings.Split(accept, ",")
+	kept := parts[:0]
+	for _, part := range parts {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if strings.TrimSpace(mediaType) != contentTypeEventStream {
+			kept = append(kept, part)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(kept, ","))
+}
+
+// eventSchemaPathPrefix prefixes the placeholder operations used to generate
+// the schemas of Server-Sent Event data. They are removed from the spec.
+const eventSchemaPathPrefix = "/__zorya/events/"
+
+// eventSchemaOperations returns placeholder operations whose response bodies
+// are the data types of the events of every event stream route, and the path
+// of the placeholder of each event by route.
+func (s *openapiState) eventSchemaOperations() ([]openapi.Operation, map[*BaseRoute]map[string]string) {
+	var ops []openapi.Operation
+	paths := make(map[*BaseRoute]map[string]string)
+
+	for _, route := range s.routes {
+		if !route.eventStream {
+			continue
+		}
+
+		paths[route] = make(map[string]string, len(route.Events))
+		for _, name := range slices.Sorted(maps.Keys(route.Events)) {
+			sample := route.Events[name]
+			if sample == nil {
+				continue
+			}
+
+			carrier := reflect.StructOf([]reflect.StructField{{
+				Name: "Body",
+				Type: reflect.TypeOf(sample),
+				Tag:  `body:"structured"`,
+			}})
+			path := eventSchemaPathPrefix + strconv.Itoa(len(ops))
+			ops = append(ops, openapi.GET(path, openapi.WithResponse(http.StatusOK, reflect.New(carrier).Elem().Interface())))
+			paths[route][name] = path
+		}
+	}
+
+	return ops, paths
+}
+
+// extractEventSchemas collects the event data schemas generated for the
+// placeholder operations and removes them from the paths.
+func extractEventSchemas(paths map[string]any, eventPaths map[*BaseRoute]map[string]string) map[*BaseRoute]map[string]any {
+	result := make(map[*BaseRoute]map[string]any, len(eventPaths))
+	for route, byName := range eventPaths {
+		result[route] = make(map[string]any, len(byName))
+		for name, path := range byName {
+			pathItem, _ := paths[path].(map[string]any)
+			operation, _ := pathItem["get"].(map[string]any)
+			responses, _ := operation["responses"].(map[string]any)
+			response, _ := responses["200"].(map[string]any)
+			content, _ := response["content"].(map[string]any)
+			media, _ := content[contentTypeJSON].(map[string]any)
+
+			result[route][name] = media["schema"]
+			delete(paths, path)
+		}
+	}
+
+	return result
+}
+
+// setEventStreamResponse documents the text/event-stream response of an event
+// stream route, with one message schema per declared event.
+func setEventStreamResponse(operation map[string]any, route *BaseRoute, dataSchemas map[string]any) {
+	status := route.DefaultStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	responses, _ := operation["responses"].(map[string]any)
+	if responses == nil {
+		responses = map[string]any{}
+		operation["responses"] = responses
+	}
+	response, _ := responses[strconv.Itoa(status)].(map[string]any)
+	if response == nil {
+		response = map[string]any{"description": http.StatusText(status)}
+		responses[strconv.Itoa(status)] = response
+	}
+
+	messages := make([]any, 0, len(route.Events))
+	for _, name := range slices.Sorted(maps.Keys(route.Events)) {
+		data := dataSchemas[name]
+		if data == nil {
+			data = map[string]any{}
+		}
+
+		messages = append(messages, map[string]any{
+			"title": "Event " + name,
+			"type":  "object",
+			"properties": map[string]any{
+				"id":    map[string]any{"type": "string"},
+				"event": map[string]any{"type": "string", "const": name},
+				"data":  data,
+				"retry": map[string]any{"type": "integer", "description": "Reconnection delay in milliseconds"},
+			},
+			"required": []any{"event", "data"},
+		})
+	}
+
+	items := map[string]any{}
+	if len(messages) > 0 {
+		items["oneOf"] = messages
+	}
+
+	response["content"] = map[string]any{
+		contentTypeEventStream: map[string]any{
+			"schema": map[string]any{
+				"title":       "Server-Sent Events",
+				"description": "Each item is one possible event, sent as UTF-8 text in the text/event-stream format.",
+				"type":        "array",
+				"items":       items,
+			},
+		},
+	}
+}
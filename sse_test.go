@@ -0,0 +1,184 @@
+package zorya
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	// Writes strings as they are, so data can contain raw line breaks
+	text := Format{
+		Marshal: func(w io.Writer, v any) error {
+			_, err := fmt.Fprint(w, v)
+
+			return err
+		},
+	}
+	api := NewAPI(&testChiAdapter{router: chi.NewMux()}, WithFormats(map[string]Format{"text/plain": text}))
+
+	t.Run("data lines", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+			want string
+		}{
+			{name: "LF", data: "a\nb", want: "data: a\ndata: b\n\n"},
+			{name: "CRLF", data: "a\r\nb", want: "data: a\ndata: b\n\n"},
+			{name: "CR", data: "a\rb", want: "data: a\ndata: b\n\n"},
+			{name: "mixed", data: "a\r\n\rb\n", want: "data: a\ndata: \ndata: b\n\n"},
+			{name: "injected event", data: "x\r\n\r\nevent: admin\rdata: y", want: "data: x\ndata: \ndata: event: admin\ndata: data: y\n\n"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				msg, err := encodeEvent(api, "text/plain", Event{Data: tt.data})
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, string(msg))
+			})
+		}
+	})
+
+	t.Run("invalid ID and name", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			event Event
+		}{
+			{name: "ID with LF", event: Event{ID: "1\nevent: admin"}},
+			{name: "ID with CR", event: Event{ID: "1\rdata: x"}},
+			{name: "ID with NUL", event: Event{ID: "1\x00"}},
+			{name: "name with LF", event: Event{Name: "a\n\ndata: x"}},
+			{name: "name with CR", event: Event{Name: "a\r"}},
+			{name: "name with NUL", event: Event{Name: "a\x00b"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.event.Data = "x"
+				_, err := encodeEvent(api, "text/plain", tt.event)
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestEventStream_RejectsInvalidEvent(t *testing.T) {
+	type EventsOutput struct {
+		Body EventStream
+	}
+
+	router := chi.NewMux()
+	api := NewAPI(&testChiAdapter{router: router}, WithMarshalErrorHandler(func(r *http.Request, ct string, err error) {}))
+
+	var sendErr error
+	Get(api, "/events", func(ctx context.Context, input *struct{}) (*EventsOutput, error) {
+		return &EventsOutput{Body: func(ctx context.Context, send EventSender) error {
+			sendErr = send(Event{ID: "1\n\nevent: admin", Data: "x"})
+
+			return send(Event{ID: "2", Data: "y"})
+		}}, nil
+	}, func(r *BaseRoute) {
+		r.HeartbeatInterval = -1
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Error(t, sendErr)
+	assert.Equal(t, "id: 2\ndata: \"y\"\n\n", recorder.Body.String())
+}
+
+func TestEventStream(t *testing.T) {
+	type UserCreated struct {
+		ID int `json:"id"`
+	}
+	type EventsInput struct {
+		EventStreamParams
+	}
+	type EventsOutput struct {
+		Body EventStream
+	}
+
+	router := chi.NewMux()
+	adapter := &testChiAdapter{router: router}
+	api := NewAPI(adapter)
+
+	Get(api, "/events", func(ctx context.Context, input *EventsInput) (*EventsOutput, error) {
+		return &EventsOutput{Body: func(ctx context.Context, send EventSender) error {
+			if err := send(Event{ID: input.LastEventID + "1", Data: UserCreated{ID: 1}, Retry: time.Second}); err != nil {
+				return err
+			}
+			if err := send.Data(map[string]string{"text": "hello"}); err != nil {
+				return err
+			}
+
+			return Error409Conflict("stream closed")
+		}}, nil
+	}, Events(map[string]any{"userCreated": UserCreated{}}), func(r *BaseRoute) {
+		r.HeartbeatInterval = -1
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "4")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 41\n"+
+		"event: userCreated\n"+
+		"retry: 1000\n"+
+		`data: {"id":1}`+"\n\n"+
+		`data: {"text":"hello"}`+"\n\n"+
+		"event: error\n"+
+		`data: {"title":"Conflict","status":409,"detail":"stream closed"}`+"\n\n", recorder.Body.String())
+
+	// Events sharing a data type are named after the first name in sorted order
+	events := map[string]any{"userUpdated": UserCreated{}, "userCreated": UserCreated{}, "userDeleted": UserCreated{}}
+	for range 10 {
+		assert.Equal(t, "userCreated", eventName(events, UserCreated{ID: 2}))
+	}
+
+	// The spec documents the event stream and its events
+	req = httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema struct {
+						Type  string `json:"type"`
+						Items struct {
+							OneOf []struct {
+								Title      string                     `json:"title"`
+								Properties map[string]json.RawMessage `json:"properties"`
+							} `json:"oneOf"`
+						} `json:"items"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	require.Len(t, spec.Paths, 1, "placeholder operations are removed")
+
+	schema := spec.Paths["/events"]["get"].Responses["200"].Content["text/event-stream"].Schema
+	assert.Equal(t, "array", schema.Type)
+	require.Len(t, schema.Items.OneOf, 1)
+	assert.Equal(t, "Event userCreated", schema.Items.OneOf[0].Title)
+	assert.JSONEq(t, `{"type": "string", "const": "userCreated"}`, string(schema.Items.OneOf[0].Properties["event"]))
+	assert.Contains(t, string(schema.Items.OneOf[0].Properties["data"]), "UserCreated")
+}
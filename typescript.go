@@ -0,0 +1,850 @@
+package zorya
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/talav/zorya/internal/openapidoc"
+)
+
+// TypeScriptConfig configures the TypeScript module generated by
+// GenerateTypeScript.
+type TypeScriptConfig struct {
+	// ClientName is the name of the generated client class. Defaults to
+	// "Client".
+	ClientName string
+
+	// TypesOnly generates the component schema types without the client.
+	TypesOnly bool
+}
+
+var (
+	tsIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	tsPathParamPattern  = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+// GenerateTypeScript generates a TypeScript module from an OpenAPI document,
+// such as the one returned by API.Spec. The module declares a type for every
+// component schema and a fetch-based client class with a method per operation:
+//
+//	const api = new Client({ baseUrl: "https://api.example.com" });
+//	const user = await api.getUser({ id: 42 });
+//
+// Methods take the operation's path, query and header parameters as an object,
+// followed by the request body, and resolve to the body of the success
+// response. Error responses are thrown as an ApiError holding the status and
+// the decoded problem document; each operation declares the union of its
+// documented errors (e.g. GetUserError). Schemas with a discriminator are
+// generated as discriminated unions.
+//
+//	spec, err := api.Spec(ctx)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	src, err := zorya.GenerateTypeScript(spec, zorya.TypeScriptConfig{})
+func GenerateTypeScript(spec []byte, config TypeScriptConfig) ([]byte, error) {
+	if config.ClientName == "" {
+		config.ClientName = "Client"
+	}
+	if !tsIdentifierPattern.MatchString(config.ClientName) {
+		return nil, fmt.Errorf("invalid client name %q", config.ClientName)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+
+	g := &tsGenerator{config: config}
+	g.writeString("// Code generated by zorya.GenerateTypeScript. DO NOT EDIT.\n/* eslint-disable */\n")
+
+	components, _ := doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(schemas)) {
+		g.writeSchema(name, schemas[name])
+	}
+
+	if !config.TypesOnly {
+		if err := g.writeClient(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(g.b.String()), nil
+}
+
+// tsGenerator writes a TypeScript module.
+type tsGenerator struct {
+	config TypeScriptConfig
+	b      strings.Builder
+}
+
+// tsOperation is an operation of the generated client.
+type tsOperation struct {
+	name      string // Method name of the client
+	typeName  string // Prefix of the operation's type names
+	method    string
+	path      string
+	operation map[string]any
+	params    []map[string]any
+}
+
+func (g *tsGenerator) writeString(s string) {
+	g.b.WriteString(s)
+}
+
+// writeSchema declares the type of a component schema: an interface for
+// object schemas and a type alias otherwise.
+func (g *tsGenerator) writeSchema(name string, schema any) {
+	g.writeString("\n")
+	s, _ := schema.(map[string]any)
+	g.writeDoc("", docText(s))
+
+	if isObjectSchema(s) {
+		fmt.Fprintf(&g.b, "export interface %s %s\n", tsTypeName(name), g.objectType(s, ""))
+
+		return
+	}
+	fmt.Fprintf(&g.b, "export type %s = %s;\n", tsTypeName(name), g.tsType(schema, ""))
+}
+
+// writeClient declares the ApiError class, the parameter and error types of
+// each operation and the client class.
+func (g *tsGenerator) writeClient(doc map[string]any) error {
+	operations, err := tsOperations(doc)
+	if err != nil {
+		return err
+	}
+
+	g.writeString(tsClientPreamble)
+
+	for _, op := range operations {
+		if fields := g.paramFields(op.params, "  "); fields != "" {
+			fmt.Fprintf(&g.b, "\nexport interface %sParams {\n%s}\n", op.typeName, fields)
+		}
+		fmt.Fprintf(&g.b, "\nexport type %sError = %s;\n", op.typeName, g.errorType(op.operation))
+	}
+
+	fmt.Fprintf(&g.b, "\nexport class %s {\n", g.config.ClientName)
+	g.writeString(tsClientFields)
+	for _, op := range operations {
+		g.writeOperation(op)
+	}
+	g.writeString(tsClientRequest)
+	g.writeString("}\n")
+
+	return nil
+}
+
+// writeOperation writes the client method of an operation.
+func (g *tsGenerator) writeOperation(op tsOperation) {
+	var args, query, headers []string
+	var pathParams map[string]string
+	if len(op.params) > 0 {
+		args = append(args, "params: "+op.typeName+"Params")
+		pathParams = map[string]string{}
+	}
+	for _, param := range op.params {
+		name, _ := param["name"].(string)
+		value := "params" + tsPropertyAccess(name)
+		switch param["in"] {
+		case "path":
+			pathParams[name] = value
+		case "query":
+			query = append(query, tsPropertyName(name)+": "+value)
+		case "header":
+			headers = append(headers, tsPropertyName(name)+": "+value)
+		default:
+		}
+	}
+
+	body, hasBody := g.requestBodyType(op.operation)
+	if hasBody {
+		required, _ := op.operation["requestBody"].(map[string]any)["required"].(bool)
+		if required {
+			args = append(args, "body: "+body)
+		} else {
+			args = append(args, "body?: "+body)
+		}
+	}
+	args = append(args, "init?: RequestInit")
+
+	result, raw := g.responseType(op.operation)
+
+	path := tsPathParamPattern.ReplaceAllStringFunc(op.path, func(placeholder string) string {
+		name := strings.TrimSuffix(placeholder[1:len(placeholder)-1], "...")
+		if value, ok := pathParams[name]; ok {
+			return "${encodeURIComponent(String(" + value + "))}"
+		}
+
+		return placeholder
+	})
+
+	g.writeString("\n")
+	lines := []string{op.method + " " + op.path}
+	if text := docText(op.operation); text != "" {
+		lines = append(lines, "", text)
+	}
+	if deprecated, _ := op.operation["deprecated"].(bool); deprecated {
+		lines = append(lines, "", "@deprecated")
+	}
+	lines = append(lines, "", "@throws {"+op.typeName+"Error}")
+	g.writeDoc("  ", strings.Join(lines, "\n"))
+
+	fmt.Fprintf(&g.b, "  async %s(%s): Promise<%s> {\n", op.name, strings.Join(args, ", "), result)
+	fmt.Fprintf(&g.b, "    return (await this.request(%s, `%s`, {\n", tsLiteral(op.method), path)
+	if len(query) > 0 {
+		fmt.Fprintf(&g.b, "      query: { %s },\n", strings.Join(query, ", "))
+	}
+	if len(headers) > 0 {
+		fmt.Fprintf(&g.b, "      headers: { %s },\n", strings.Join(headers, ", "))
+	}
+	if hasBody {
+		g.writeString("      body,\n")
+	}
+	if raw {
+		g.writeString("      raw: true,\n")
+	}
+	fmt.Fprintf(&g.b, "    }, init)) as %s;\n  }\n", result)
+}
+
+// tsOperations returns the operations of the document, sorted by path and
+// method, with the parameters of their path items.
+func tsOperations(doc map[string]any) ([]tsOperation, error) {
+	paths, _ := doc["paths"].(map[string]any)
+	names := map[string]string{}
+
+	var operations []tsOperation
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem, _ := paths[path].(map[string]any)
+
+		type entry struct {
+			method    string
+			operation map[string]any
+		}
+		var entries []entry
+		for _, method := range openapidoc.Methods(pathItem) {
+			entries = append(entries, entry{method, openapidoc.PathItemOperation(pathItem, method)})
+		}
+
+		for _, e := range entries {
+			typeName := derivedOperationName(e.method, path)
+			if id, _ := e.operation["operationId"].(string); id != "" {
+				typeName = goIdentifier(id)
+			}
+			if other, ok := names[typeName]; ok {
+				return nil, fmt.Errorf("operations %s and %s %s are both generated as %s", other, e.method, path, typeName)
+			}
+			names[typeName] = e.method + " " + path
+
+			operations = append(operations, tsOperation{
+				name:      lowerFirst(typeName),
+				typeName:  typeName,
+				method:    e.method,
+				path:      path,
+				operation: e.operation,
+				params:    operationParams(pathItem, e.operation),
+			})
+		}
+	}
+
+	return operations, nil
+}
+
+// operationParams returns the path, query and header parameters of the
+// operation, including those of its path item it does not override. Cookies
+// are left to the browser.
+func operationParams(pathItem, operation map[string]any) []map[string]any {
+	var params []map[string]any
+	seen := map[string]bool{}
+	for _, list := range []any{operation["parameters"], pathItem["parameters"]} {
+		items, _ := list.([]any)
+		for _, item := range items {
+			param, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			key := fmt.Sprint(param["in"], ":", param["name"])
+			if seen[key] || param["in"] == "cookie" {
+				continue
+			}
+			seen[key] = true
+			params = append(params, param)
+		}
+	}
+
+	return params
+}
+
+// paramFields returns the fields of an operation's parameters interface.
+func (g *tsGenerator) paramFields(params []map[string]any, indent string) string {
+	var b strings.Builder
+	for _, param := range params {
+		name, _ := param["name"].(string)
+		required, _ := param["required"].(bool)
+		if text := docText(param); text != "" {
+			b.WriteString(tsDoc(indent, text))
+		}
+		optional := "?"
+		if required {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s;\n", indent, tsPropertyName(name), optional, g.tsType(param["schema"], indent))
+	}
+
+	return b.String()
+}
+
+// requestBodyType returns the type of the operation's JSON request body.
+// Reports false if the operation has no request body.
+func (g *tsGenerator) requestBodyType(operation map[string]any) (string, bool) {
+	requestBody, ok := operation["requestBody"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	content, _ := requestBody["content"].(map[string]any)
+	if media := jsonMediaType(content); media != nil {
+		return g.tsType(media["schema"], "    "), true
+	}
+
+	return "unknown", true
+}
+
+// responseType returns the type the operation's method resolves to: the union
+// of its success response bodies, void for responses without content, Blob
+// for other media types, or Response for event streams, which are returned
+// unread (raw).
+func (g *tsGenerator) responseType(operation map[string]any) (string, bool) {
+	responses, _ := operation["responses"].(map[string]any)
+
+	var types []string
+	for _, status := range slices.Sorted(maps.Keys(responses)) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		response, _ := responses[status].(map[string]any)
+		content, _ := response["content"].(map[string]any)
+
+		switch media := jsonMediaType(content); {
+		case media != nil:
+			types = append(types, g.tsType(media["schema"], "  "))
+		case content["text/event-stream"] != nil:
+			return "Response", true
+		case len(content) > 0:
+			types = append(types, "Blob")
+		default:
+			types = append(types, "void")
+		}
+	}
+	if len(types) == 0 {
+		return "unknown", false
+	}
+
+	return tsUnion(types), false
+}
+
+// errorType returns the union of ApiError types of the operation's error
+// responses, by status.
+func (g *tsGenerator) errorType(operation map[string]any) string {
+	responses, _ := operation["responses"].(map[string]any)
+
+	var types []string
+	for _, status := range slices.Sorted(maps.Keys(responses)) {
+		code := "number"
+		if n, err := strconv.Atoi(status); err == nil {
+			if n < 400 {
+				continue
+			}
+			code = status
+		} else if status != "default" && !strings.HasPrefix(status, "4") && !strings.HasPrefix(status, "5") {
+			continue
+		}
+
+		problem := "unknown"
+		response, _ := responses[status].(map[string]any)
+		content, _ := response["content"].(map[string]any)
+		if media := jsonMediaType(content); media != nil {
+			problem = g.tsType(media["schema"], "")
+		}
+		types = append(types, "ApiError<"+code+", "+problem+">")
+	}
+	if len(types) == 0 {
+		return "ApiError"
+	}
+
+	return strings.Join(types, " | ")
+}
+
+// jsonMediaType returns the media type object for JSON of the content map:
+// application/json, or a type with a +json suffix such as
+// application/problem+json. Returns nil if there is none.
+func jsonMediaType(content map[string]any) map[string]any {
+	if media, ok := content[contentTypeJSON].(map[string]any); ok {
+		return media
+	}
+	for _, ct := range slices.Sorted(maps.Keys(content)) {
+		if strings.HasSuffix(ct, "+json") {
+			media, _ := content[ct].(map[string]any)
+
+			return media
+		}
+	}
+
+	return nil
+}
+
+// tsType returns the TypeScript type of a JSON Schema. indent is the
+// indentation of the line the type starts on, for nested object types.
+func (g *tsGenerator) tsType(schema any, indent string) string {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		if allowed, isBool := schema.(bool); isBool && !allowed {
+			return "never"
+		}
+
+		return "unknown"
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		return tsTypeName(ref[strings.LastIndex(ref, "/")+1:])
+	}
+
+	nullable, _ := s["nullable"].(bool)
+	withNull := func(t string) string {
+		if nullable {
+			return tsUnion([]string{t, "null"})
+		}
+
+		return t
+	}
+
+	if value, ok := s["const"]; ok {
+		return withNull(tsLiteral(value))
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		literals := make([]string, 0, len(enum))
+		for _, value := range enum {
+			literals = append(literals, tsLiteral(value))
+		}
+
+		return withNull(tsUnion(literals))
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if members, ok := s[keyword].([]any); ok {
+			if discriminator, ok := s["discriminator"].(map[string]any); ok {
+				return withNull(g.discriminatedUnion(members, discriminator, indent))
+			}
+
+			types := make([]string, 0, len(members))
+			for _, member := range members {
+				types = append(types, g.tsType(member, indent))
+			}
+
+			return withNull(tsUnion(types))
+		}
+	}
+	if members, ok := s["allOf"].([]any); ok {
+		types := make([]string, 0, len(members))
+		for _, member := range members {
+			types = append(types, parenthesize(g.tsType(member, indent)))
+		}
+
+		return withNull(strings.Join(types, " & "))
+	}
+
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+	if len(types) == 0 {
+		if _, ok := s["properties"]; !ok {
+			return withNull("unknown")
+		}
+		types = []string{"object"}
+	}
+
+	result := make([]string, 0, len(types))
+	for _, t := range types {
+		switch t {
+		case "string":
+			if s["format"] == "binary" {
+				result = append(result, "Blob")
+			} else {
+				result = append(result, "string")
+			}
+		case "integer", "number":
+			result = append(result, "number")
+		case "boolean":
+			result = append(result, "boolean")
+		case "null":
+			result = append(result, "null")
+		case "array":
+			result = append(result, parenthesize(g.tsType(s["items"], indent))+"[]")
+		case "object":
+			result = append(result, g.objectType(s, indent))
+		default:
+			result = append(result, "unknown")
+		}
+	}
+
+	return withNull(tsUnion(result))
+}
+
+// objectType returns the TypeScript type of an object schema: a type literal
+// with its properties, or a Record for maps.
+func (g *tsGenerator) objectType(s map[string]any, indent string) string {
+	properties, _ := s["properties"].(map[string]any)
+
+	var additional string
+	switch value := s["additionalProperties"].(type) {
+	case map[string]any:
+		additional = g.tsType(value, indent+"  ")
+	case bool:
+		if value && len(properties) == 0 {
+			additional = "unknown"
+		}
+	}
+
+	if len(properties) == 0 {
+		if additional == "" {
+			return "Record<string, unknown>"
+		}
+
+		return "Record<string, " + additional + ">"
+	}
+
+	required := map[string]bool{}
+	if list, ok := s["required"].([]any); ok {
+		for _, name := range list {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		property, _ := properties[name].(map[string]any)
+		if text := docText(property); text != "" {
+			b.WriteString(tsDoc(indent+"  ", text))
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		readOnly := ""
+		if ro, _ := property["readOnly"].(bool); ro {
+			readOnly = "readonly "
+		}
+		fmt.Fprintf(&b, "%s  %s%s%s: %s;\n", indent, readOnly, tsPropertyName(name), optional, g.tsType(properties[name], indent+"  "))
+	}
+	if additional != "" {
+		fmt.Fprintf(&b, "%s  [key: string]: %s;\n", indent, additional)
+	}
+	b.WriteString(indent + "}")
+
+	return b.String()
+}
+
+// discriminatedUnion returns the union of the member schemas, each
+// intersected with its literal discriminator property, so TypeScript narrows
+// the union on that property. Member values are taken from the mapping, or
+// default to the component name.
+func (g *tsGenerator) discriminatedUnion(members []any, discriminator map[string]any, indent string) string {
+	property, _ := discriminator["propertyName"].(string)
+	mapping, _ := discriminator["mapping"].(map[string]any)
+
+	values := map[string]string{}
+	for _, value := range slices.Sorted(maps.Keys(mapping)) {
+		if ref, ok := mapping[value].(string); ok {
+			if _, exists := values[ref]; !exists {
+				values[ref] = value
+			}
+		}
+	}
+
+	types := make([]string, 0, len(members))
+	for _, member := range members {
+		ref, _ := member.(map[string]any)["$ref"].(string)
+		if ref == "" || property == "" {
+			types = append(types, g.tsType(member, indent))
+
+			continue
+		}
+
+		value, ok := values[ref]
+		if !ok {
+			value = ref[strings.LastIndex(ref, "/")+1:]
+		}
+		types = append(types, fmt.Sprintf("(%s & { %s: %s })", g.tsType(member, indent), tsPropertyName(property), tsLiteral(value)))
+	}
+
+	return tsUnion(types)
+}
+
+// writeDoc writes a JSDoc comment with the text, if any.
+func (g *tsGenerator) writeDoc(indent, text string) {
+	if text != "" {
+		g.writeString(tsDoc(indent, text))
+	}
+}
+
+// isObjectSchema reports whether the schema is a plain object with
+// properties, declared as an interface.
+func isObjectSchema(s map[string]any) bool {
+	if _, ok := s["properties"].(map[string]any); !ok {
+		return false
+	}
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf", "enum", "const", "nullable"} {
+		if _, ok := s[keyword]; ok {
+			return false
+		}
+	}
+	t, ok := s["type"].(string)
+
+	return !ok || t == "object"
+}
+
+// docText returns the title and description of a schema or operation.
+func docText(s map[string]any) string {
+	var parts []string
+	for _, key := range []string{"summary", "title", "description"} {
+		if text, _ := s[key].(string); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// tsDoc returns a JSDoc comment with the text.
+func tsDoc(indent, text string) string {
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 1 {
+		return indent + "/** " + lines[0] + " */\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		if line = strings.TrimRight(line, " \t\r"); line == "" {
+			b.WriteString(indent + " *\n")
+		} else {
+			b.WriteString(indent + " * " + line + "\n")
+		}
+	}
+	b.WriteString(indent + " */\n")
+
+	return b.String()
+}
+
+// tsUnion joins types into a union, dropping duplicates.
+func tsUnion(types []string) string {
+	var unique []string
+	for _, t := range types {
+		if !slices.Contains(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	if len(unique) == 0 {
+		return "never"
+	}
+
+	return strings.Join(unique, " | ")
+}
+
+// parenthesize wraps union and intersection types in parentheses.
+func parenthesize(t string) string {
+	if strings.Contains(t, " | ") || strings.Contains(t, " & ") {
+		return "(" + t + ")"
+	}
+
+	return t
+}
+
+// tsLiteral returns the TypeScript literal of a JSON value.
+func tsLiteral(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "unknown"
+	}
+
+	return string(data)
+}
+
+// tsTypeName converts a component schema name to a TypeScript identifier.
+func tsTypeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return '_'
+	}, name)
+	if first, _ := utf8.DecodeRuneInString(name); unicode.IsDigit(first) {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// tsPropertyName returns the property name, quoted if it is not an identifier.
+func tsPropertyName(name string) string {
+	if tsIdentifierPattern.MatchString(name) {
+		return name
+	}
+
+	return tsLiteral(name)
+}
+
+// tsPropertyAccess returns the property access expression for the name.
+func tsPropertyAccess(name string) string {
+	if tsIdentifierPattern.MatchString(name) {
+		return "." + name
+	}
+
+	return "[" + tsLiteral(name) + "]"
+}
+
+// lowerFirst lowers the leading upper case letters of an identifier, keeping
+// the last one before a lower case letter (IDToken becomes idToken).
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := range n {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	return string(runes)
+}
+
+// tsClientPreamble declares the client options and the ApiError class.
+const tsClientPreamble = `
+/** Options of the API client. */
+export interface ClientOptions {
+  /** Base URL of the API, including any base path. */
+  baseUrl: string;
+  /** Headers sent with every request. */
+  headers?: HeadersInit;
+  /** fetch implementation, defaults to the global fetch. */
+  fetch?: typeof fetch;
+}
+
+/** Error thrown for responses with a 4xx or 5xx status. */
+export class ApiError<S extends number = number, P = unknown> extends Error {
+  /** Status of the response. */
+  readonly status: S;
+  /** Decoded problem document (RFC 9457), or the response text. */
+  readonly problem: P;
+  /** The response, with its body consumed. */
+  readonly response: Response;
+
+  constructor(status: S, problem: P, response: Response) {
+    const detail = typeof problem === "object" && problem !== null ? (problem as { detail?: unknown }).detail : problem;
+    super(typeof detail === "string" && detail !== "" ? detail : ` + "`HTTP ${status}`" + `);
+    this.name = "ApiError";
+    this.status = status;
+    this.problem = problem;
+    this.response = response;
+  }
+}
+
+interface RequestOptions {
+  query?: Record<string, unknown>;
+  headers?: Record<string, unknown>;
+  body?: unknown;
+  raw?: boolean;
+}
+`
+
+// tsClientFields declares the constructor of the client class.
+const tsClientFields = `  private readonly baseUrl: string;
+  private readonly headers?: HeadersInit;
+  private readonly fetch: typeof fetch;
+
+  constructor(options: ClientOptions) {
+    this.baseUrl = options.baseUrl.replace(/\/+$/, "");
+    this.headers = options.headers;
+    this.fetch = options.fetch ?? globalThis.fetch.bind(globalThis);
+  }
+`
+
+// tsClientRequest declares the method sending requests: query values are
+// repeated for arrays, undefined values are omitted, bodies are sent as JSON
+// and error responses are thrown as ApiError.
+const tsClientRequest = `
+  private async request(method: string, path: string, options: RequestOptions, init?: RequestInit): Promise<unknown> {
+    const search = new URLSearchParams();
+    for (const [name, value] of Object.entries(options.query ?? {})) {
+      for (const item of Array.isArray(value) ? value : [value]) {
+        if (item !== undefined && item !== null) {
+          search.append(name, item instanceof Date ? item.toISOString() : String(item));
+        }
+      }
+    }
+    const query = search.toString();
+
+    const headers = new Headers(this.headers);
+    new Headers(init?.headers).forEach((value, name) => headers.set(name, value));
+    for (const [name, value] of Object.entries(options.headers ?? {})) {
+      if (value !== undefined && value !== null) {
+        headers.set(name, Array.isArray(value) ? value.join(", ") : String(value));
+      }
+    }
+    if (!headers.has("Accept")) {
+      headers.set("Accept", "application/json");
+    }
+
+    let body: BodyInit | undefined;
+    if (options.body !== undefined) {
+      headers.set("Content-Type", "application/json");
+      body = JSON.stringify(options.body);
+    }
+
+    const response = await this.fetch(this.baseUrl + path + (query ? "?" + query : ""), { ...init, method, headers, body });
+    if (!response.ok) {
+      const text = await response.text();
+      let problem: unknown = text;
+      try {
+        problem = JSON.parse(text);
+      } catch {
+        // Not a JSON problem document
+      }
+      throw new ApiError(response.status, problem, response);
+    }
+    if (options.raw) {
+      return response;
+    }
+    if (response.status === 204 || response.status === 304 || method === "HEAD") {
+      return undefined;
+    }
+    if (/[/+]json\b/.test(response.headers.get("Content-Type") ?? "")) {
+      const text = await response.text();
+      return text === "" ? undefined : JSON.parse(text);
+    }
+    return response.blob();
+  }
+`
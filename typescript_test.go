@@ -0,0 +1,102 @@
+package zorya
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	spec := `{
+	  "openapi": "3.1.0",
+	  "info": {"title": "Pets", "version": "1.0.0"},
+	  "paths": {
+	    "/users/{id}": {
+	      "get": {
+	        "operationId": "get-user",
+	        "summary": "Get a user",
+	        "parameters": [
+	          {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+	          {"name": "verbose", "in": "query", "schema": {"type": "boolean"}},
+	          {"name": "X-Tenant", "in": "header", "schema": {"type": "string"}},
+	          {"name": "session", "in": "cookie", "schema": {"type": "string"}}
+	        ],
+	        "responses": {
+	          "200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+	          "404": {"description": "Not Found", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/ErrorModel"}}}},
+	          "500": {"description": "Internal Server Error", "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/ErrorModel"}}}}
+	        }
+	      }
+	    },
+	    "/pets": {
+	      "post": {
+	        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+	        "responses": {"204": {"description": "No Content"}}
+	      }
+	    },
+	    "/events": {
+	      "get": {
+	        "responses": {"200": {"description": "OK", "content": {"text/event-stream": {"schema": {"type": "string"}}}}}
+	      }
+	    }
+	  },
+	  "components": {
+	    "schemas": {
+	      "User": {
+	        "type": "object",
+	        "description": "A registered user.",
+	        "required": ["id", "name"],
+	        "properties": {
+	          "id": {"type": "integer"},
+	          "name": {"type": "string"},
+	          "email": {"type": ["string", "null"]},
+	          "tags": {"type": "array", "items": {"type": "string", "enum": ["admin", "staff"]}},
+	          "meta": {"type": "object", "additionalProperties": {"type": "number"}}
+	        }
+	      },
+	      "Cat": {"type": "object", "properties": {"kind": {"type": "string"}, "lives": {"type": "integer"}}},
+	      "Dog": {"type": "object", "properties": {"kind": {"type": "string"}, "breed": {"type": "string"}}},
+	      "Pet": {
+	        "oneOf": [{"$ref": "#/components/schemas/Cat"}, {"$ref": "#/components/schemas/Dog"}],
+	        "discriminator": {"propertyName": "kind", "mapping": {"cat": "#/components/schemas/Cat"}}
+	      },
+	      "ErrorModel": {
+	        "type": "object",
+	        "properties": {"status": {"type": "integer"}, "detail": {"type": "string"}}
+	      }
+	    }
+	  }
+	}`
+
+	src, err := GenerateTypeScript([]byte(spec), TypeScriptConfig{ClientName: "PetsClient"})
+	require.NoError(t, err)
+	code := string(src)
+
+	// Component schemas
+	assert.Contains(t, code, "/** A registered user. */\nexport interface User {\n")
+	assert.Contains(t, code, "  id: number;\n")
+	assert.Contains(t, code, "  email?: string | null;\n")
+	assert.Contains(t, code, `  tags?: ("admin" | "staff")[];`)
+	assert.Contains(t, code, "  meta?: Record<string, number>;\n")
+	assert.Contains(t, code, `export type Pet = (Cat & { kind: "cat" }) | (Dog & { kind: "Dog" });`)
+
+	// Operations
+	assert.Contains(t, code, "export interface GetUserParams {\n  id: number;\n  verbose?: boolean;\n  \"X-Tenant\"?: string;\n}\n")
+	assert.NotContains(t, code, "session")
+	assert.Contains(t, code, "export type GetUserError = ApiError<404, ErrorModel> | ApiError<500, ErrorModel>;")
+	assert.Contains(t, code, "export class PetsClient {")
+	assert.Contains(t, code, "  async getUser(params: GetUserParams, init?: RequestInit): Promise<User> {\n")
+	assert.Contains(t, code, "this.request(\"GET\", `/users/${encodeURIComponent(String(params.id))}`, {\n")
+	assert.Contains(t, code, `      query: { verbose: params.verbose },`)
+	assert.Contains(t, code, `      headers: { "X-Tenant": params["X-Tenant"] },`)
+	assert.Contains(t, code, "  async postPets(body: Pet, init?: RequestInit): Promise<void> {\n")
+	assert.Contains(t, code, "  async getEvents(init?: RequestInit): Promise<Response> {\n")
+	assert.Contains(t, code, "      raw: true,\n")
+
+	// Types only
+	src, err = GenerateTypeScript([]byte(spec), TypeScriptConfig{TypesOnly: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "export interface User {")
+	assert.NotContains(t, string(src), "class")
+}
@@ -0,0 +1,155 @@
+// Package zoryacli provides the spec commands of the zorya CLI, to export the
+// OpenAPI spec of an API at build time and to fail CI on breaking changes.
+//
+// Usage:
+//
+//	Call Main from a main package that builds your API without listening:
+//
+//		func main() {
+//			api := zorya.NewAPI(adapters.NewStdlib(http.NewServeMux()))
+//			routes.Register(api)
+//
+//			zoryacli.Main(api)
+//		}
+//
+//	Then export the spec and compare it with the previous one:
+//
+//		go run ./cmd/spec export -o openapi.json
+//		go run ./cmd/spec diff main/openapi.json openapi.json
+//
+// The diff command exits with status 1 if the revision has breaking changes
+// and its info.version equals the base one. It does not need the API, so it is
+// also available from the zorya command:
+//
+//	go run github.com/talav/zorya/cmd/zorya diff old.json new.json
+package zoryacli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/specdiff"
+)
+
+// ErrUsage is returned by Run for invalid command lines. Main exits with
+// status 2 for it.
+var ErrUsage = errors.New("invalid usage")
+
+const usage = `Usage:
+  %[1]s export [-o file] [-yaml] [-openapi30]
+        Write the OpenAPI spec of the API to a file or stdout.
+  %[1]s diff base revision
+        List the changes between two specs and fail on breaking changes
+        without an info.version bump.
+`
+
+// Main runs the command given by os.Args and exits with status 1 if it fails,
+// or 2 if the command line is invalid. api may be nil if only the diff command
+// is used.
+func Main(api zorya.API) {
+	name := "zorya"
+	if len(os.Args) > 0 {
+		name = os.Args[0]
+	}
+
+	err := Run(context.Background(), api, name, os.Args[1:], os.Stdout)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUsage):
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintf(os.Stderr, usage, name)
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run runs the command given by args, writing its output to stdout. name is
+// the program name used in messages.
+func Run(ctx context.Context, api zorya.API, name string, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", ErrUsage)
+	}
+
+	switch args[0] {
+	case "export":
+		return runExport(ctx, api, name, args[1:], stdout)
+	case "diff":
+		return runDiff(name, args[1:], stdout)
+	default:
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+	}
+}
+
+func runExport(ctx context.Context, api zorya.API, name string, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(name+" export", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	output := flags.String("o", "", "file to write the spec to, stdout if empty")
+	yaml := flags.Bool("yaml", false, "encode the spec as YAML")
+	openAPI30 := flags.Bool("openapi30", false, "downgrade the spec to OpenAPI 3.0.3")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected arguments %v", ErrUsage, flags.Args())
+	}
+
+	if api == nil {
+		return errors.New("export needs the API: call zoryacli.Main(api) from a main package that builds it")
+	}
+
+	spec, err := zorya.ExportSpec(ctx, api, zorya.SpecOptions{YAML: *yaml, OpenAPI30: *openAPI30})
+	if err != nil {
+		return fmt.Errorf("failed to generate OpenAPI spec: %w", err)
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(spec)
+
+		return err
+	}
+
+	return os.WriteFile(*output, spec, 0o644)
+}
+
+func runDiff(name string, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(name+" diff", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("%w: diff needs a base and a revision spec", ErrUsage)
+	}
+
+	base, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	revision, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report, err := specdiff.Diff(base, revision)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range report.Changes {
+		if _, err := fmt.Fprintln(stdout, change); err != nil {
+			return err
+		}
+	}
+	if len(report.Changes) == 0 {
+		_, _ = fmt.Fprintln(stdout, "no changes")
+	}
+
+	return report.Err()
+}
@@ -0,0 +1,98 @@
+package zoryacli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/adapters"
+	"github.com/talav/zorya/zoryacli"
+)
+
+type GetPetInput struct {
+	ID int `schema:"id,location=path,required=true"`
+}
+
+type GetPetOutput struct {
+	Body struct {
+		Name string `json:"name"`
+	} `body:"structured"`
+}
+
+func newAPI() zorya.API {
+	api := zorya.NewAPI(adapters.NewStdlib(http.NewServeMux()))
+	zorya.Get(api, "/pets/{id}", func(ctx context.Context, input *GetPetInput) (*GetPetOutput, error) {
+		return &GetPetOutput{}, nil
+	})
+
+	return api
+}
+
+func TestRun_Export(t *testing.T) {
+	api := newAPI()
+	dir := t.TempDir()
+	output := filepath.Join(dir, "openapi.json")
+
+	require.NoError(t, zoryacli.Run(context.Background(), api, "spec", []string{"export", "-o", output}, nil))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	spec, err := api.Spec(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, spec, data)
+
+	var stdout bytes.Buffer
+	require.NoError(t, zoryacli.Run(context.Background(), api, "spec", []string{"export", "-yaml", "-openapi30"}, &stdout))
+	assert.Contains(t, stdout.String(), "openapi: 3.0.3\n")
+}
+
+func TestRun_Diff(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, spec map[string]any) string {
+		data, err := json.Marshal(spec)
+		require.NoError(t, err)
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		return path
+	}
+	spec := func(version string, paths map[string]any) map[string]any {
+		return map[string]any{"openapi": "3.1.2", "info": map[string]any{"title": "Pets", "version": version}, "paths": paths}
+	}
+	pets := map[string]any{"/pets": map[string]any{"get": map[string]any{"responses": map[string]any{}}}}
+
+	base := write("base.json", spec("1.0.0", pets))
+	unchanged := write("unchanged.json", spec("1.0.0", pets))
+	removed := write("removed.json", spec("1.0.0", map[string]any{}))
+	bumped := write("bumped.json", spec("2.0.0", map[string]any{}))
+
+	var stdout bytes.Buffer
+	require.NoError(t, zoryacli.Run(context.Background(), nil, "zorya", []string{"diff", base, unchanged}, &stdout))
+	assert.Equal(t, "no changes\n", stdout.String())
+
+	stdout.Reset()
+	err := zoryacli.Run(context.Background(), nil, "zorya", []string{"diff", base, removed}, &stdout)
+	require.ErrorContains(t, err, "1 breaking changes without a version bump")
+	assert.Equal(t, "[BREAKING] GET /pets: operation removed\n", stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, zoryacli.Run(context.Background(), nil, "zorya", []string{"diff", base, bumped}, &stdout))
+	assert.Equal(t, "[BREAKING] GET /pets: operation removed\n", stdout.String())
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"publish"}, {"diff", "base.json"}, {"export", "-unknown"}} {
+		err := zoryacli.Run(context.Background(), nil, "zorya", args, &bytes.Buffer{})
+		assert.ErrorIs(t, err, zoryacli.ErrUsage, "%v", args)
+	}
+
+	err := zoryacli.Run(context.Background(), nil, "zorya", []string{"export"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "export needs the API")
+}
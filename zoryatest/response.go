@@ -0,0 +1,197 @@
+package zoryatest
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/talav/schema"
+	"github.com/talav/zorya"
+)
+
+// Response is the recorded response of a test request.
+type Response struct {
+	*httptest.ResponseRecorder
+
+	api zorya.API
+	tb  testing.TB
+}
+
+// Unmarshal decodes the response body into v with the format for the response
+// Content-Type.
+func (r *Response) Unmarshal(v any) error {
+	ct := r.Header().Get("Content-Type")
+	if ct == "" {
+		ct = "application/json"
+	}
+
+	return r.api.Unmarshal(ct, r.Body.Bytes(), v)
+}
+
+// Error decodes the response body as an RFC 9457 problem. The test fails if
+// the body cannot be decoded.
+func (r *Response) Error() *zorya.ErrorModel {
+	r.tb.Helper()
+
+	model := &zorya.ErrorModel{}
+	if err := r.Unmarshal(model); err != nil {
+		r.tb.Fatalf("zoryatest: failed to decode error response (status %d): %v", r.Code, err)
+	}
+
+	return model
+}
+
+// Decode decodes the response into the route's output type O: header fields
+// are parsed from the response headers and the body field is decoded with the
+// format for the response Content-Type. Streaming bodies are left unset. The
+// test fails if the response cannot be decoded.
+func Decode[O any](resp *Response) *O {
+	resp.tb.Helper()
+
+	output := new(O)
+	if err := decodeOutput(resp, reflect.ValueOf(output).Elem()); err != nil {
+		resp.tb.Fatalf("zoryatest: failed to decode response (status %d) into %T: %v", resp.Code, output, err)
+	}
+
+	return output
+}
+
+// decodeOutput fills the header and body fields of the output struct vo.
+func decodeOutput(resp *Response, vo reflect.Value) error {
+	structMeta, err := resp.api.Metadata().GetStructMetadata(vo.Type())
+	if err != nil {
+		return fmt.Errorf("failed to get struct metadata: %w", err)
+	}
+
+	for i := range structMeta.Fields {
+		fieldMeta := &structMeta.Fields[i]
+
+		schemaMeta, ok := schema.GetTagMetadata[*schema.SchemaMetadata](fieldMeta, "schema")
+		if !ok || schemaMeta.Location != schema.LocationHeader {
+			continue
+		}
+
+		values := resp.Header().Values(schemaMeta.ParamName)
+		if len(values) == 0 {
+			continue
+		}
+		if err := setHeaderField(vo.Field(fieldMeta.Index), values); err != nil {
+			return fmt.Errorf("header %s: %w", schemaMeta.ParamName, err)
+		}
+	}
+
+	bodyFieldMeta := zorya.FindBodyField(structMeta)
+	if bodyFieldMeta == nil || resp.Body.Len() == 0 {
+		return nil
+	}
+
+	bodyField := vo.Field(bodyFieldMeta.Index)
+	switch {
+	case bodyField.Kind() == reflect.Func:
+		return nil
+	case bodyField.Type() == reflect.TypeFor[[]byte]():
+		bodyField.SetBytes(resp.Body.Bytes())
+
+		return nil
+	default:
+		return resp.Unmarshal(bodyField.Addr().Interface())
+	}
+}
+
+// setHeaderField parses header values into field. Slices receive every value;
+// other types receive the first one.
+func setHeaderField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setHeaderValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+
+		return nil
+	}
+
+	return setHeaderValue(field, values[0])
+}
+
+// setHeaderValue parses a single header value into v.
+func setHeaderValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return setHeaderValue(v.Elem(), value)
+	}
+
+	if v.Type() == reflect.TypeFor[time.Time]() {
+		t, err := parseTime(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported header field type %s", v.Type())
+	}
+
+	return nil
+}
+
+// timeLayouts are the layouts time header values are parsed with: HTTP dates,
+// RFC 3339 and the time.Time.String format Zorya writes by default.
+var timeLayouts = []string{http.TimeFormat, time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"}
+
+// parseTime parses a time header value in any of timeLayouts.
+func parseTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
@@ -0,0 +1,111 @@
+package zoryatest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/internal/openapidoc"
+)
+
+// specValidator checks responses against the generated OpenAPI spec. The
+// validator is cached until the spec changes.
+type specValidator struct {
+	mu        sync.Mutex
+	spec      []byte
+	paths     map[string]any // Path items of the spec by path template
+	validator *openapidoc.ResponseValidator
+}
+
+// check validates the recorded response to req against the spec. Responses to
+// requests that match no documented operation (e.g. the docs endpoints) are
+// not checked. Bodies are only validated against their schema for JSON media
+// types; for other formats only the status and media type are checked.
+func (v *specValidator) check(api zorya.API, req *http.Request, rec *httptest.ResponseRecorder) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.load(api, req); err != nil {
+		return err
+	}
+
+	path := matchPath(v.paths, req.URL.Path)
+	if path == "" {
+		return nil
+	}
+
+	var body []byte
+	if req.Method != http.MethodHead {
+		body = rec.Body.Bytes()
+	}
+
+	return v.validator.Check(path, req.Method, rec.Code, rec.Header().Get("Content-Type"), body)
+}
+
+// load parses the current spec of api unless it is already cached.
+func (v *specValidator) load(api zorya.API, req *http.Request) error {
+	spec, err := api.Spec(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to generate spec: %w", err)
+	}
+	if v.validator != nil && bytes.Equal(spec, v.spec) {
+		return nil
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(spec))
+	if err != nil {
+		return fmt.Errorf("failed to decode spec: %w", err)
+	}
+	object, _ := doc.(map[string]any)
+
+	v.spec = spec
+	v.paths, _ = object["paths"].(map[string]any)
+	v.validator = openapidoc.NewResponseValidator(object)
+
+	return nil
+}
+
+// matchPath returns the spec path template matching the request path, or an
+// empty string if there is none. Templates with more literal segments win.
+func matchPath(paths map[string]any, requestPath string) string {
+	segments := strings.Split(requestPath, "/")
+
+	best, bestLiterals := "", -1
+	for template := range paths {
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+
+		literals := 0
+		matched := true
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				if segments[i] == "" {
+					matched = false
+
+					break
+				}
+
+				continue
+			}
+			if part != segments[i] {
+				matched = false
+
+				break
+			}
+			literals++
+		}
+
+		if matched && (literals > bestLiterals || literals == bestLiterals && template < best) {
+			best, bestLiterals = template, literals
+		}
+	}
+
+	return best
+}
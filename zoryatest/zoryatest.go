@@ -0,0 +1,192 @@
+// Package zoryatest provides helpers for testing Zorya APIs in-process,
+// without starting a server.
+//
+// Requests are served directly by the API's adapter, request bodies are
+// encoded with the API's registered formats, and every response is checked
+// against the generated OpenAPI schema for its operation and status:
+//
+//	func TestGetUser(t *testing.T) {
+//		api := zoryatest.New(t)
+//		zorya.Get(api, "/users/{id}", getUser)
+//
+//		resp := api.Get("/users/{id}", zoryatest.Params{"id": 42})
+//		require.Equal(t, http.StatusOK, resp.Code)
+//
+//		out := zoryatest.Decode[GetUserOutput](resp)
+//		assert.Equal(t, 42, out.Body.ID)
+//	}
+package zoryatest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/adapters"
+)
+
+// Params holds path parameter values, substituted for the matching
+// {name} placeholders of the request path.
+type Params map[string]any
+
+// API is a Zorya API served in-process for tests.
+type API struct {
+	zorya.API
+
+	tb     testing.TB
+	schema *specValidator
+}
+
+// New creates an API over an in-memory http.ServeMux adapter. Options are
+// passed to zorya.NewAPI.
+func New(tb testing.TB, opts ...zorya.Option) *API {
+	tb.Helper()
+
+	return Wrap(tb, zorya.NewAPI(adapters.NewStdlib(http.NewServeMux()), opts...))
+}
+
+// Wrap returns test helpers for an existing API, served by its adapter.
+func Wrap(tb testing.TB, api zorya.API) *API {
+	return &API{API: api, tb: tb, schema: &specValidator{}}
+}
+
+// Get performs a GET request. See Do for the supported arguments.
+func (a *API) Get(path string, args ...any) *Response {
+	a.tb.Helper()
+
+	return a.Do(http.MethodGet, path, args...)
+}
+
+// Post performs a POST request. See Do for the supported arguments.
+func (a *API) Post(path string, args ...any) *Response {
+	a.tb.Helper()
+
+	return a.Do(http.MethodPost, path, args...)
+}
+
+// Put performs a PUT request. See Do for the supported arguments.
+func (a *API) Put(path string, args ...any) *Response {
+	a.tb.Helper()
+
+	return a.Do(http.MethodPut, path, args...)
+}
+
+// Patch performs a PATCH request. See Do for the supported arguments.
+func (a *API) Patch(path string, args ...any) *Response {
+	a.tb.Helper()
+
+	return a.Do(http.MethodPatch, path, args...)
+}
+
+// Delete performs a DELETE request. See Do for the supported arguments.
+func (a *API) Delete(path string, args ...any) *Response {
+	a.tb.Helper()
+
+	return a.Do(http.MethodDelete, path, args...)
+}
+
+// Do performs a request against the API and checks the response against the
+// OpenAPI spec. Each argument is one of:
+//   - Params: path parameter values for the {name} placeholders in path
+//   - url.Values: query parameters
+//   - http.Header: request headers
+//   - string: a single "Name: value" request header
+//   - io.Reader or []byte: the raw request body
+//   - any other value: the request body, encoded with the format for the
+//     request Content-Type (JSON if no Content-Type header is given)
+//
+// The test fails if the request cannot be built or the response does not
+// conform to the spec.
+func (a *API) Do(method, path string, args ...any) *Response {
+	a.tb.Helper()
+
+	req, err := a.newRequest(method, path, args)
+	if err != nil {
+		a.tb.Fatalf("zoryatest: %s %s: %v", method, path, err)
+	}
+
+	rec := httptest.NewRecorder()
+	a.Adapter().ServeHTTP(rec, req)
+
+	if err := a.schema.check(a.API, req, rec); err != nil {
+		a.tb.Errorf("zoryatest: %s %s: response does not match the OpenAPI spec: %v", method, req.URL.Path, err)
+	}
+
+	return &Response{ResponseRecorder: rec, api: a.API, tb: a.tb}
+}
+
+// newRequest builds a request from the arguments described in Do.
+func (a *API) newRequest(method, path string, args []any) (*http.Request, error) {
+	header := http.Header{}
+	query := url.Values{}
+	var body io.Reader
+	var value any
+	hasValue := false
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case Params:
+			for name, param := range v {
+				path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(fmt.Sprint(param)))
+			}
+		case url.Values:
+			for name, values := range v {
+				query[name] = append(query[name], values...)
+			}
+		case http.Header:
+			for name, values := range v {
+				for _, headerValue := range values {
+					header.Add(name, headerValue)
+				}
+			}
+		case string:
+			name, headerValue, ok := strings.Cut(v, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", v)
+			}
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(headerValue))
+		case io.Reader:
+			body = v
+		case []byte:
+			body = bytes.NewReader(v)
+		default:
+			value = v
+			hasValue = true
+		}
+	}
+
+	if hasValue {
+		ct := header.Get("Content-Type")
+		if ct == "" {
+			ct = "application/json"
+			header.Set("Content-Type", ct)
+		}
+
+		var buf bytes.Buffer
+		if err := a.Marshal(&buf, ct, value); err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = &buf
+	}
+
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + query.Encode()
+	}
+
+	req := httptest.NewRequestWithContext(a.tb.Context(), method, path, body)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	return req, nil
+}
@@ -0,0 +1,137 @@
+package zoryatest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/talav/zorya"
+	"github.com/talav/zorya/zoryatest"
+)
+
+type User struct {
+	ID   int    `json:"id" validate:"min=1"`
+	Name string `json:"name"`
+}
+
+type GetUserInput struct {
+	ID      int    `schema:"id,location=path,required=true"`
+	Verbose bool   `schema:"verbose,location=query"`
+	Tenant  string `schema:"X-Tenant,location=header"`
+}
+
+type GetUserOutput struct {
+	Tenant string `schema:"X-Tenant,location=header"`
+	Body   User   `body:"structured"`
+}
+
+type CreateUserInput struct {
+	Body struct {
+		Name string `json:"name" validate:"required"`
+	} `body:"structured"`
+}
+
+type CreateUserOutput struct {
+	Body User `body:"structured"`
+}
+
+func newTestAPI(tb testing.TB) *zoryatest.API {
+	api := zoryatest.New(tb, zorya.WithValidator(zorya.NewPlaygroundValidator(validator.New())))
+
+	zorya.Get(api, "/users/{id}", func(ctx context.Context, input *GetUserInput) (*GetUserOutput, error) {
+		if input.ID == 404 {
+			return nil, zorya.Error404NotFound("user not found")
+		}
+
+		out := &GetUserOutput{Tenant: input.Tenant}
+		out.Body = User{ID: input.ID, Name: fmt.Sprintf("verbose=%t", input.Verbose)}
+
+		return out, nil
+	}, func(r *zorya.BaseRoute) {
+		r.Errors = []int{http.StatusNotFound}
+	})
+
+	zorya.Post(api, "/users", func(ctx context.Context, input *CreateUserInput) (*CreateUserOutput, error) {
+		return &CreateUserOutput{Body: User{ID: 1, Name: input.Body.Name}}, nil
+	})
+
+	return api
+}
+
+func TestAPI_Get(t *testing.T) {
+	api := newTestAPI(t)
+
+	resp := api.Get("/users/{id}",
+		zoryatest.Params{"id": 42},
+		url.Values{"verbose": {"true"}},
+		"X-Tenant: acme",
+	)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	out := zoryatest.Decode[GetUserOutput](resp)
+	assert.Equal(t, "acme", out.Tenant)
+	assert.Equal(t, User{ID: 42, Name: "verbose=true"}, out.Body)
+}
+
+func TestAPI_PostEncodesBody(t *testing.T) {
+	api := newTestAPI(t)
+
+	resp := api.Post("/users", map[string]any{"name": "Alice"})
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	out := zoryatest.Decode[CreateUserOutput](resp)
+	assert.Equal(t, "Alice", out.Body.Name)
+
+	// Bodies are encoded with the format for the given Content-Type
+	resp = api.Post("/users", map[string]any{"name": "Bob"}, "Content-Type: application/cbor")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "Bob", zoryatest.Decode[CreateUserOutput](resp).Body.Name)
+}
+
+func TestAPI_Error(t *testing.T) {
+	api := newTestAPI(t)
+
+	resp := api.Get("/users/404")
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Equal(t, "user not found", resp.Error().Detail)
+
+	resp = api.Post("/users", map[string]any{})
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.NotEmpty(t, resp.Error().Errors)
+}
+
+// recordingTB records errors instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAPI_SchemaMismatch(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	api := newTestAPI(tb)
+
+	// Conforming responses pass
+	api.Get("/users/1")
+	assert.Empty(t, tb.errors)
+
+	// A transformer that breaks the documented schema is reported
+	api.UseTransformer(func(r *http.Request, status int, v any) (any, error) {
+		if out, ok := v.(*GetUserOutput); ok {
+			out.Body.ID = 0
+		}
+
+		return v, nil
+	})
+	api.Get("/users/1")
+	require.Len(t, tb.errors, 1)
+	assert.Contains(t, tb.errors[0], "response does not match the OpenAPI spec")
+}